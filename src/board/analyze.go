@@ -0,0 +1,132 @@
+package board
+
+import (
+	"context"
+	"math"
+	"math/bits"
+	"time"
+)
+
+// EndgameDepth is the number of empty squares at which Analyze stops
+// estimating and solves the position exactly.
+const EndgameDepth = 12
+
+// Analysis is what a search makes of a position.
+type Analysis struct {
+	Moves []Move   // every legal move of the side to move, best first
+	PV    []string // the line both sides are expected to play, "pass" for a pass
+	Depth int      // plies searched
+	Exact bool     // scores are final disc differences rather than estimates
+}
+
+// finalScore is the disc difference at the end of a game, empty squares
+// going to the winner.
+func finalScore(player, opponent uint64) int {
+	p, o := bits.OnesCount64(player), bits.OnesCount64(opponent)
+	empty := 64 - p - o
+	switch {
+	case p > o:
+		return p - o + empty
+	case o > p:
+		return p - o - empty
+	}
+	return 0
+}
+
+// solver is an Evaluation that plays out the game; it is exact for depths
+// of at least the number of empty squares.
+func solver(ctx context.Context) Evaluation {
+	alphaBeta := MakeAlphaBetaContext(ctx, finalScore)
+	return func(player, opponent uint64, depth int) int {
+		return alphaBeta(player, opponent, depth, -math.MaxInt, math.MaxInt)
+	}
+}
+
+// Solve returns the final disc difference for player, to move, with best
+// play by both sides.
+func Solve(ctx context.Context, player, opponent uint64) (int, error) {
+	score := solver(ctx)(player, opponent, bits.OnesCount64(^(player | opponent)))
+	return score, ctxErr(ctx)
+}
+
+// Analyze scores every move in b for the side to move and finds the
+// principal variation. It searches to depth plies, or with a moveTime
+// deepens a ply at a time until that runs out, stopping at depth unless it
+// is 0. Positions with at most EndgameDepth empty squares are solved.
+func Analyze(ctx context.Context, b Board, depth int, moveTime time.Duration) (Analysis, error) {
+	player, opponent := b.White, b.Black
+	if b.BlackTurn {
+		player, opponent = b.Black, b.White
+	}
+
+	empty := bits.OnesCount64(b.Empty())
+	if empty <= EndgameDepth {
+		return AnalyzeExact(ctx, b)
+	}
+
+	if moveTime <= 0 {
+		return analyze(ctx, Pengwin{}.search, player, opponent, depth, false)
+	}
+
+	budget, cancel := context.WithTimeout(ctx, moveTime)
+	defer cancel()
+
+	limit := empty
+	if depth > 0 && depth < limit {
+		limit = depth
+	}
+
+	var best Analysis
+	for d := 1; d <= limit; d++ {
+		// The first ply always finishes so there is an answer.
+		searchCtx := budget
+		if d == 1 {
+			searchCtx = ctx
+		}
+
+		a, err := analyze(searchCtx, Pengwin{}.search, player, opponent, d, false)
+		if err != nil {
+			break
+		}
+		best = a
+	}
+	return best, ctxErr(ctx)
+}
+
+// AnalyzeExact solves b, scoring every move by the final disc difference
+// whatever the number of empty squares. Each one more takes several times
+// as long, so ctx should bound a search from early in the game.
+func AnalyzeExact(ctx context.Context, b Board) (Analysis, error) {
+	player, opponent := b.White, b.Black
+	if b.BlackTurn {
+		player, opponent = b.Black, b.White
+	}
+	return analyze(ctx, solver, player, opponent, bits.OnesCount64(b.Empty()), true)
+}
+
+// analyze searches to depth with the evaluation search builds, then
+// follows the best replies to find the principal variation.
+func analyze(ctx context.Context, search func(context.Context) Evaluation, player, opponent uint64, depth int, exact bool) (Analysis, error) {
+	eval := search(ctx)
+	a := Analysis{Moves: eval.Search(player, opponent, depth), Depth: depth, Exact: exact}
+
+	moves := a.Moves
+	for depth > 0 && !gameOver(player, opponent) {
+		if len(moves) == 0 {
+			a.PV = append(a.PV, "pass")
+			player, opponent = opponent, player
+		} else {
+			best := moves[0]
+			a.PV = append(a.PV, SquareName(best.X, best.Y))
+
+			move := SqureToBit(best.X, best.Y)
+			flips := flip(player, opponent, move)
+			player, opponent = opponent&^flips, player|move|flips
+			depth--
+		}
+		if depth > 0 {
+			moves = eval.Search(player, opponent, depth)
+		}
+	}
+	return a, ctxErr(ctx)
+}
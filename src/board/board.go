@@ -2,8 +2,10 @@ package board
 
 import (
 	"fmt"
+	"io"
 	"math/bits"
 	"math/rand"
+	"os"
 )
 
 type Board struct {
@@ -51,23 +53,47 @@ func NewBoard() Board {
 }
 
 func (b *Board) DisplayBoard() {
+	b.Draw(os.Stdout, false, 0, 0)
+}
+
+// Draw writes the board as DisplayBoard does, with the squares in hints
+// marked. With colour it uses ANSI escapes for a green board and
+// highlights the squares in last.
+func (b *Board) Draw(w io.Writer, color bool, hints, last uint64) {
 	for row := 7; row >= 0; row-- {
-		fmt.Printf("%d ", row+1)
+		fmt.Fprintf(w, "%d ", row+1)
 		for col := 7; col >= 0; col-- {
 			idx := row*8 + col
 			mask := uint64(1) << idx
+
+			glyph, fg := ".", 30
 			switch {
 			case b.Black&mask != 0:
-				fmt.Print("○ ")
+				glyph = "○"
+				if color {
+					glyph = "●"
+				}
 			case b.White&mask != 0:
-				fmt.Print("● ")
-			default:
-				fmt.Print(". ")
+				glyph, fg = "●", 97
+			case hints&mask != 0:
+				glyph = "·"
+			case color:
+				glyph = " "
+			}
+
+			if !color {
+				fmt.Fprint(w, glyph+" ")
+				continue
 			}
+			bg := 42
+			if last&mask != 0 {
+				bg = 43
+			}
+			fmt.Fprintf(w, "\x1b[%d;%dm%s \x1b[0m", fg, bg, glyph)
 		}
-		fmt.Println()
+		fmt.Fprintln(w)
 	}
-	fmt.Println("  A B C D E F G H")
+	fmt.Fprintln(w, "  A B C D E F G H")
 }
 
 func (b Board) Empty() uint64 {
@@ -156,7 +182,35 @@ func flip(player, opp, move uint64) uint64 {
 	return toFlip
 }
 
+// Flips returns the discs that playing (x, y) would flip for the side to
+// move; 0 means the move is not legal.
+func (b *Board) Flips(x, y int) uint64 {
+	move := SqureToBit(x, y)
+	if move&(b.Black|b.White) != 0 {
+		return 0
+	}
+	if b.BlackTurn {
+		return flip(b.Black, b.White, move)
+	}
+	return flip(b.White, b.Black, move)
+}
+
+// MoveErrorCode tells apart the reasons a move is rejected, for callers
+// that react to each differently.
+type MoveErrorCode string
+
+const (
+	CodeInvalidFormat MoveErrorCode = "invalid_format" // not a square name
+	CodeOccupied      MoveErrorCode = "occupied"       // the square has a disc
+	CodeNoFlips       MoveErrorCode = "no_flips"       // the move flips nothing
+	CodeNoLegalMove   MoveErrorCode = "no_legal_move"  // the side to move must pass
+	CodeNotYourTurn   MoveErrorCode = "not_your_turn"  // the other player is to move
+	CodeGameOver      MoveErrorCode = "game_over"      // nobody can move
+	CodeOutOfTime     MoveErrorCode = "out_of_time"    // the player's clock ran out
+)
+
 type InvalidMoveError struct {
+	Code   MoveErrorCode
 	Reason string
 }
 
@@ -173,14 +227,14 @@ func (b *Board) PlayXY(x, y int) error {
 	move := SqureToBit(x, y)
 
 	if move&(b.Black|b.White) != 0 {
-		return &InvalidMoveError{Reason: "move not allowed"}
+		return &InvalidMoveError{Code: CodeOccupied, Reason: "square occupied"}
 	}
 
 	if b.BlackTurn {
 		flipped = flip(b.Black, b.White, move)
 
 		if flipped == 0 {
-			return &InvalidMoveError{Reason: "move not allowed"}
+			return &InvalidMoveError{Code: CodeNoFlips, Reason: "move flips no discs"}
 		}
 
 		b.Black |= move | flipped
@@ -193,7 +247,7 @@ func (b *Board) PlayXY(x, y int) error {
 		flipped = flip(b.White, b.Black, move)
 
 		if flipped == 0 {
-			return &InvalidMoveError{Reason: "move not allowed"}
+			return &InvalidMoveError{Code: CodeNoFlips, Reason: "move flips no discs"}
 		}
 
 		b.White |= move | flipped
@@ -209,24 +263,39 @@ func (b *Board) PlayXY(x, y int) error {
 }
 
 func (b *Board) Play(move string) error {
+	x, y, err := ParseSquare(move)
+	if err != nil {
+		return err
+	}
+
+	return b.PlayXY(x, y)
+}
+
+// ParseSquare converts algebraic notation ("a1".."h8") to board coordinates.
+func ParseSquare(move string) (x, y int, err error) {
 	if len(move) != 2 {
-		return &InvalidMoveError{Reason: "invalid format"}
+		return 0, 0, &InvalidMoveError{Code: CodeInvalidFormat, Reason: "invalid format"}
 	}
 
 	file := move[0]
 	rank := move[1]
 
 	if file < 'a' || file > 'h' {
-		return &InvalidMoveError{Reason: "invalid file"}
+		return 0, 0, &InvalidMoveError{Code: CodeInvalidFormat, Reason: "invalid file"}
 	}
-	x := int('h' - file)
+	x = int('h' - file)
 
 	if rank < '1' || rank > '8' {
-		return &InvalidMoveError{Reason: "invalid rank"}
+		return 0, 0, &InvalidMoveError{Code: CodeInvalidFormat, Reason: "invalid rank"}
 	}
-	y := int(rank - '1')
+	y = int(rank - '1')
 
-	return b.PlayXY(x, y)
+	return x, y, nil
+}
+
+// SquareName is the inverse of ParseSquare.
+func SquareName(x, y int) string {
+	return string([]byte{byte('h' - x), byte('1' + y)})
 }
 
 func gameOver(player1, player2 uint64) bool {
@@ -237,6 +306,38 @@ func (b *Board) GameOver() bool {
 	return gameOver(b.Black, b.White)
 }
 
+// LegalMoves returns the moves available to the side to move.
+func (b *Board) LegalMoves() uint64 {
+	if b.BlackTurn {
+		return Moves(b.Black, b.White)
+	}
+	return Moves(b.White, b.Black)
+}
+
+// Validate reports whether a game can be played from b, as set up by hand:
+// no square holds both colours, the four centre squares are taken, as they
+// are in every game, and the side to move has a move.
+func (b *Board) Validate() error {
+	if b.Black&b.White != 0 {
+		return fmt.Errorf("black and white share squares")
+	}
+	centre := SqureToBit(3, 3) | SqureToBit(4, 3) | SqureToBit(3, 4) | SqureToBit(4, 4)
+	if (b.Black|b.White)&centre != centre {
+		return fmt.Errorf("the four centre squares must be taken")
+	}
+	if b.GameOver() {
+		return fmt.Errorf("neither side has a move")
+	}
+	if b.LegalMoves() == 0 {
+		side, other := "white", "black"
+		if b.BlackTurn {
+			side, other = other, side
+		}
+		return fmt.Errorf("%s has no move, so it must be %s to move", side, other)
+	}
+	return nil
+}
+
 /*	Abstraction of the MiniMax/AlphaBeta
 
 func MiniMax(state GameState, depth int) int {
@@ -360,7 +461,8 @@ func CountPositionsSimple(player, opponent uint64, depth int) int {
 	return count
 }
 
-func (b *Board) PlayRandomMove() error {
+// PlayRandomMove plays a legal move drawn from r.
+func (b *Board) PlayRandomMove(r *rand.Rand) error {
 	var moves uint64
 	if b.BlackTurn {
 		moves = Moves(b.Black, b.White)
@@ -369,11 +471,11 @@ func (b *Board) PlayRandomMove() error {
 	}
 
 	if moves == 0 {
-		return &InvalidMoveError{Reason: "no legal move"}
+		return &InvalidMoveError{Code: CodeNoLegalMove, Reason: "no legal move"}
 	}
 
 	count := bits.OnesCount64(moves)
-	choice := rand.Intn(count)
+	choice := intn(r, count)
 
 	for i := 0; i < 64; i++ {
 		if (moves>>i)&1 == 1 {
@@ -384,6 +486,6 @@ func (b *Board) PlayRandomMove() error {
 		}
 	}
 
-	return &InvalidMoveError{Reason: "random move failed"}
+	return &InvalidMoveError{Code: CodeNoLegalMove, Reason: "random move failed"}
 }
 
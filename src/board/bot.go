@@ -1,17 +1,79 @@
 package board
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"math"
 	"math/bits"
 	"math/rand"
+	"os"
 	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
 func MakeAlphaBetaFunc(eval func(uint64, uint64) int) func(player, opponent uint64, depth, alpha, beta int) int {
+	return MakeAlphaBetaContext(context.Background(), eval)
+}
+
+// checkEvery is how many nodes a search visits between looks at its
+// context.
+const checkEvery = 1024
+
+// nodeLimit is a number of positions left to search, shared by every
+// search made with a context carrying it.
+type nodeLimit struct{ left int }
+
+type nodeLimitKey struct{}
+
+var errNodeLimit = errors.New("node limit reached")
+
+func withNodeLimit(ctx context.Context, nodes int) context.Context {
+	return context.WithValue(ctx, nodeLimitKey{}, &nodeLimit{left: nodes})
+}
+
+// ctxErr is ctx.Err(), except that a deadline counts as exceeded as soon
+// as it passes rather than when ctx's timer gets to run, which on a busy
+// machine can be late enough to lose a game on time. A node limit that
+// has run out counts too.
+func ctxErr(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok && !time.Now().Before(deadline) {
+		return context.DeadlineExceeded
+	}
+	if limit, ok := ctx.Value(nodeLimitKey{}).(*nodeLimit); ok && limit.left < 0 {
+		return errNodeLimit
+	}
+	return nil
+}
+
+// MakeAlphaBetaContext is MakeAlphaBetaFunc for a search that gives up once
+// ctx is done, past its deadline or out of nodes. The scores of a search
+// that gave up are meaningless, so callers check ctxErr before using them.
+func MakeAlphaBetaContext(ctx context.Context, eval func(uint64, uint64) int) func(player, opponent uint64, depth, alpha, beta int) int {
 	var alphaBeta func(player, opponent uint64, depth, alpha, beta int) int
+	nodes, stopped := 0, false
+	limit, _ := ctx.Value(nodeLimitKey{}).(*nodeLimit)
 
 	alphaBeta = func(player, opponent uint64, depth, alpha, beta int) int {
+		if nodes++; nodes%checkEvery == 0 && ctxErr(ctx) != nil {
+			stopped = true
+		}
+		if limit != nil {
+			if limit.left--; limit.left < 0 {
+				stopped = true
+			}
+		}
+		if stopped {
+			return 0
+		}
+
 		if depth == 0 || gameOver(player, opponent) {
 			return eval(player, opponent)
 		}
@@ -107,24 +169,134 @@ type Player interface {
 	GetMove(b *Board) (x, y int, ok bool)
 }
 
-type HumanPlayer struct{}
+// ContextPlayer is a Player whose search can be cancelled.
+type ContextPlayer interface {
+	Player
+	GetMoveContext(ctx context.Context, b *Board) (x, y int, ok bool)
+}
+
+// GetMoveContext asks p for a move, stopping its search when ctx is done
+// if p is a ContextPlayer.
+func GetMoveContext(ctx context.Context, p Player, b *Board) (int, int, bool) {
+	if cp, ok := p.(ContextPlayer); ok {
+		return cp.GetMoveContext(ctx, b)
+	}
+	return p.GetMove(b)
+}
+
+// TimedPlayer is a Player that manages its own time, given the clock of
+// its side.
+type TimedPlayer interface {
+	Player
+	GetMoveTimed(ctx context.Context, b *Board, clock Clock) (x, y int, ok bool)
+}
+
+// GetMoveTimed asks p for a move with clock running, letting a TimedPlayer
+// plan its time. The caller enforces the clock, typically by ending ctx
+// when clock.Available runs out.
+func GetMoveTimed(ctx context.Context, p Player, b *Board, clock Clock) (int, int, bool) {
+	if tp, ok := p.(TimedPlayer); ok && clock.Limited() {
+		return tp.GetMoveTimed(ctx, b, clock)
+	}
+	return GetMoveContext(ctx, p, b)
+}
+
+// HumanPlayer asks for moves on Out and reads them from In, by default
+// stdout and stdin. It must be used as a pointer so that input read ahead
+// is kept for the next move.
+type HumanPlayer struct {
+	In  io.Reader
+	Out io.Writer
 
-func (HumanPlayer) GetMove(b *Board) (int, int, bool) {
-	var x, y int
-	fmt.Print("Enter your move (x y): ")
-	_, err := fmt.Scan(&x, &y)
-	if err != nil {
-		fmt.Println("Invalid input.")
+	in *bufio.Reader // In, buffered on first use
+}
+
+// GetMove asks again until it reads a legal move, as a square such as "f5"
+// or as "x y", and gives up only when the input ends.
+func (h *HumanPlayer) GetMove(b *Board) (int, int, bool) {
+	if h.in == nil {
+		in := h.In
+		if in == nil {
+			in = os.Stdin
+		}
+		h.in = bufio.NewReader(in)
+	}
+	out := h.Out
+	if out == nil {
+		out = os.Stdout
+	}
+
+	for {
+		fmt.Fprint(out, "Enter your move (e.g. f5): ")
+		line, err := h.in.ReadString('\n')
+		if err == io.EOF && strings.TrimSpace(line) == "" {
+			return 0, 0, false
+		}
+
+		x, y, err := parseHumanMove(line)
+		if err != nil {
+			fmt.Fprintln(out, "Invalid input.")
+			continue
+		}
+		if b.LegalMoves()&SqureToBit(x, y) == 0 {
+			fmt.Fprintln(out, "Illegal move.")
+			continue
+		}
+		return x, y, true
+	}
+}
+
+func parseHumanMove(line string) (x, y int, err error) {
+	fields := strings.Fields(strings.ToLower(line))
+	switch len(fields) {
+	case 1:
+		return ParseSquare(fields[0])
+	case 2:
+		if x, err = strconv.Atoi(fields[0]); err != nil {
+			return 0, 0, err
+		}
+		if y, err = strconv.Atoi(fields[1]); err != nil {
+			return 0, 0, err
+		}
+		if x < 0 || x > 7 || y < 0 || y > 7 {
+			return 0, 0, fmt.Errorf("off the board")
+		}
+		return x, y, nil
+	}
+	return 0, 0, fmt.Errorf("invalid format")
+}
+
+// RandomPlayer plays a uniformly random legal move, drawn from Rand.
+type RandomPlayer struct {
+	Rand *rand.Rand
+}
+
+func (p RandomPlayer) GetMove(b *Board) (int, int, bool) {
+	moves := b.LegalMoves()
+	if moves == 0 {
 		return 0, 0, false
 	}
+
+	choice := intn(p.Rand, bits.OnesCount64(moves))
+	for ; choice > 0; choice-- {
+		moves &= moves - 1
+	}
+	x, y := BitToSquare(moves & -moves)
 	return x, y, true
 }
 
 type Evaluation func(player, opponent uint64, depth int) int
 
 func (eval Evaluation) Search(player, opponent uint64, depth int) []Move {
+	return eval.SearchProgress(player, opponent, depth, nil)
+}
+
+// SearchProgress is Search calling progress, if not nil, after each root
+// move with the number of moves searched so far.
+func (eval Evaluation) SearchProgress(player, opponent uint64, depth int, progress func(done, total int)) []Move {
 	movesSlice := []Move{}
 	moves := Moves(player, opponent)
+	total := bits.OnesCount64(moves)
 
 	for moveBits := moves; moveBits != 0; {
 		idx := bits.TrailingZeros64(moveBits)
@@ -138,6 +310,10 @@ func (eval Evaluation) Search(player, opponent uint64, depth int) []Move {
 		score := -eval(newOpponent, newPlayer, depth-1)
 		x, y := BitToSquare(move)
 		movesSlice = append(movesSlice, Move{X: x, Y: y, Score: score})
+
+		if progress != nil {
+			progress(len(movesSlice), total)
+		}
 	}
 
 	sort.Slice(movesSlice, func(i, j int) bool {
@@ -147,10 +323,15 @@ func (eval Evaluation) Search(player, opponent uint64, depth int) []Move {
 	return movesSlice
 }	
 
-func (eval Evaluation) SelectMove(player, opponent uint64, depth int) (Move, bool) {
+func (eval Evaluation) SelectMove(player, opponent uint64, depth int, r *rand.Rand) (Move, bool) {
 	// fmt.Println("\033[1;31mBot play now!\033[0m")
 
-	moves := eval.Search(player, opponent, depth)
+	return selectBest(eval.Search(player, opponent, depth), r)
+}
+
+// selectBest picks at random, drawing from r, among the best of moves
+// sorted by score.
+func selectBest(moves []Move, r *rand.Rand) (Move, bool) {
 	if len(moves) == 0 {
 		return Move{}, false
 	}
@@ -166,28 +347,145 @@ func (eval Evaluation) SelectMove(player, opponent uint64, depth int) (Move, boo
 		}
 	}
 
-	return bestMoves[rand.Intn(len(bestMoves))], true
+	return bestMoves[intn(r, len(bestMoves))], true
+}
+
+// choose picks the move to play from moves sorted by score, weakened as
+// the bot's Noise and Blunder ask.
+func (bot Bot) choose(moves []Move) (Move, bool) {
+	if bot.Noise > 0 {
+		moves = append([]Move(nil), moves...)
+		for i := range moves {
+			moves[i].Score += intn(bot.Rand, 2*bot.Noise+1) - bot.Noise
+		}
+		sort.SliceStable(moves, func(i, j int) bool {
+			return moves[i].Score > moves[j].Score
+		})
+	}
+
+	// Each blunder passes over the moves of one score for the next lower.
+	i := 0
+	for bot.Blunder > 0 && float64From(bot.Rand) < bot.Blunder {
+		j := i + 1
+		for j < len(moves) && moves[j].Score == moves[i].Score {
+			j++
+		}
+		if j >= len(moves) {
+			break
+		}
+		i = j
+	}
+	return selectBest(moves[i:], bot.Rand)
 }
 
 type Bot struct {
 	Depth int
 	Side  string // "black" or "white"
+
+	// Time and Nodes, if set, make the bot deepen its search a ply at a
+	// time until either budget is spent, going no deeper than Depth
+	// unless that is 0. Nodes counts the positions searched for a move.
+	Time  time.Duration
+	Nodes int
+
+	// Noise, if set, adds up to Noise either way to the score of each move
+	// before the bot chooses, and Blunder is the chance of it passing over
+	// the best move for the next best, tried again for each one passed
+	// over. Both weaken the bot for casual play; see Level.
+	Noise   int
+	Blunder float64
+
+	// Rand, if set, makes the bot's random choices, among equal moves and
+	// for Noise and Blunder, so that a seed replays them.
+	Rand *rand.Rand
+
+	// Progress, if set, is told how many root moves have been searched.
+	Progress func(done, total int)
 }
 
-func (bot Bot) GetBotMove(b *Board, eval Evaluation) (int, int, bool) {
-	var player, opponent uint64
+func (bot Bot) sides(b *Board) (player, opponent uint64) {
 	if bot.Side == "black" {
-		player = b.Black
-		opponent = b.White
-	} else {
-		player = b.White
-		opponent = b.Black
+		return b.Black, b.White
+	}
+	return b.White, b.Black
+}
+
+func (bot Bot) GetBotMove(b *Board, eval Evaluation) (int, int, bool) {
+	player, opponent := bot.sides(b)
+
+	move, ok := bot.choose(eval.SearchProgress(player, opponent, bot.Depth, bot.Progress))
+	return move.X, move.Y, ok
+}
+
+// GetBotMoveContext is GetBotMove for an evaluation built by search, which
+// gives up once its context is done. It fails if ctx ends before there is
+// a move; when the time or node budget runs out it plays the best move of
+// the deepest search that finished.
+func (bot Bot) GetBotMoveContext(ctx context.Context, b *Board, search func(context.Context) Evaluation) (int, int, bool) {
+	player, opponent := bot.sides(b)
+
+	if bot.Time <= 0 && bot.Nodes <= 0 {
+		moves := search(ctx).SearchProgress(player, opponent, bot.Depth, bot.Progress)
+		if ctxErr(ctx) != nil {
+			return 0, 0, false
+		}
+		move, ok := bot.choose(moves)
+		return move.X, move.Y, ok
+	}
+
+	budget := ctx
+	if bot.Time > 0 {
+		var cancel context.CancelFunc
+		budget, cancel = context.WithTimeout(ctx, bot.Time)
+		defer cancel()
+	}
+	if bot.Nodes > 0 {
+		budget = withNodeLimit(budget, bot.Nodes)
+	}
+
+	// Searching deeper than the number of empty squares finds nothing new.
+	limit := bits.OnesCount64(^(player | opponent))
+	if bot.Depth > 0 && bot.Depth < limit {
+		limit = bot.Depth
 	}
 
-	move, ok := eval.SelectMove(player, opponent, bot.Depth)
+	var best []Move
+	for depth := 1; depth <= limit; depth++ {
+		// The first ply always finishes so there is a move to play.
+		searchCtx := budget
+		if depth == 1 {
+			searchCtx = ctx
+		}
+
+		moves := search(searchCtx).SearchProgress(player, opponent, depth, bot.Progress)
+		if ctxErr(searchCtx) != nil {
+			break
+		}
+		best = moves
+	}
+
+	if ctxErr(ctx) != nil {
+		return 0, 0, false
+	}
+	move, ok := bot.choose(best)
 	return move.X, move.Y, ok
 }
 
+// GetBotMoveTimed is GetBotMoveContext under a clock: the search gets the
+// time clock.Budget allows, or bot.Time if that is less. A forced move is
+// played at once.
+func (bot Bot) GetBotMoveTimed(ctx context.Context, b *Board, clock Clock, search func(context.Context) Evaluation) (int, int, bool) {
+	if moves := b.LegalMoves(); bits.OnesCount64(moves) == 1 {
+		x, y := BitToSquare(moves)
+		return x, y, true
+	}
+
+	if budget := clock.Budget(b); budget > 0 && (bot.Time <= 0 || budget < bot.Time) {
+		bot.Time = budget
+	}
+	return bot.GetBotMoveContext(ctx, b, search)
+}
+
 // Pengwin Bot
 type Pengwin struct {
 	Bot
@@ -208,11 +506,26 @@ func (Pengwin) evaluate(player, opponent uint64) int {
 }
 
 func (p Pengwin) Score(player, opponent uint64, depth int) int {
-	return MakeAlphaBetaFunc(p.evaluate)(player, opponent, depth, -math.MaxInt, math.MaxInt)
+	return p.search(context.Background())(player, opponent, depth)
+}
+
+func (p Pengwin) search(ctx context.Context) Evaluation {
+	alphaBeta := MakeAlphaBetaContext(ctx, p.evaluate)
+	return func(player, opponent uint64, depth int) int {
+		return alphaBeta(player, opponent, depth, -math.MaxInt, math.MaxInt)
+	}
 }
 
 func (p Pengwin) GetMove(b *Board) (int, int, bool) {
-	return p.GetBotMove(b, Evaluation(p.Score))
+	return p.GetMoveContext(context.Background(), b)
+}
+
+func (p Pengwin) GetMoveContext(ctx context.Context, b *Board) (int, int, bool) {
+	return p.GetBotMoveContext(ctx, b, p.search)
+}
+
+func (p Pengwin) GetMoveTimed(ctx context.Context, b *Board, clock Clock) (int, int, bool) {
+	return p.GetBotMoveTimed(ctx, b, clock, p.search)
 }
 
 // Greedy Bot
@@ -229,9 +542,24 @@ func (Greedy) evaluate(player, opponent uint64) int {
 }
 
 func (g Greedy) Score(player, opponent uint64, depth int) int {
-	return MakeAlphaBetaFunc(g.evaluate)(player, opponent, depth, math.MinInt, math.MaxInt)
+	return g.search(context.Background())(player, opponent, depth)
+}
+
+func (g Greedy) search(ctx context.Context) Evaluation {
+	alphaBeta := MakeAlphaBetaContext(ctx, g.evaluate)
+	return func(player, opponent uint64, depth int) int {
+		return alphaBeta(player, opponent, depth, math.MinInt, math.MaxInt)
+	}
 }
 
 func (g Greedy) GetMove(b *Board) (int, int, bool) {
-	return g.GetBotMove(b, Evaluation(g.Score))
+	return g.GetMoveContext(context.Background(), b)
+}
+
+func (g Greedy) GetMoveContext(ctx context.Context, b *Board) (int, int, bool) {
+	return g.GetBotMoveContext(ctx, b, g.search)
+}
+
+func (g Greedy) GetMoveTimed(ctx context.Context, b *Board, clock Clock) (int, int, bool) {
+	return g.GetBotMoveTimed(ctx, b, clock, g.search)
 }
@@ -0,0 +1,155 @@
+package board
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+	"time"
+)
+
+// TimeControl is the thinking time a player gets for a game: Base to begin
+// with and Increment more after each move. Once Base is spent every move
+// may take up to Byoyomi, which is not carried over. The zero value is no
+// limit.
+type TimeControl struct {
+	Base      time.Duration
+	Increment time.Duration
+	Byoyomi   time.Duration
+}
+
+// ParseTimeControl reads a time control written as BASE[+INCREMENT][/BYOYOMI]
+// in Go durations, such as "5m+2s" or "10m/30s"; "" is no limit.
+func ParseTimeControl(s string) (TimeControl, error) {
+	var tc TimeControl
+	if strings.TrimSpace(s) == "" {
+		return tc, nil
+	}
+
+	rest, byoyomi, hasByoyomi := strings.Cut(s, "/")
+	base, increment, hasIncrement := strings.Cut(rest, "+")
+	parse := func(part string, d *time.Duration) error {
+		v, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil || v < 0 {
+			return fmt.Errorf("bad time control %q: want BASE[+INCREMENT][/BYOYOMI], e.g. 5m+2s", s)
+		}
+		*d = v
+		return nil
+	}
+
+	if err := parse(base, &tc.Base); err != nil {
+		return tc, err
+	}
+	if hasIncrement {
+		if err := parse(increment, &tc.Increment); err != nil {
+			return tc, err
+		}
+	}
+	if hasByoyomi {
+		if err := parse(byoyomi, &tc.Byoyomi); err != nil {
+			return tc, err
+		}
+	}
+	if !tc.Limited() {
+		return tc, fmt.Errorf("bad time control %q: no base time or byoyomi", s)
+	}
+	return tc, nil
+}
+
+// String writes tc as ParseTimeControl reads it.
+func (tc TimeControl) String() string {
+	if !tc.Limited() {
+		return ""
+	}
+	s := tc.Base.String()
+	if tc.Increment > 0 {
+		s += "+" + tc.Increment.String()
+	}
+	if tc.Byoyomi > 0 {
+		s += "/" + tc.Byoyomi.String()
+	}
+	return s
+}
+
+// Limited reports whether tc limits the players' time at all.
+func (tc TimeControl) Limited() bool {
+	return tc.Base > 0 || tc.Byoyomi > 0
+}
+
+// Clock is the time a player has left under a TimeControl.
+type Clock struct {
+	TimeControl
+	Left time.Duration // main time left, not counting byoyomi
+}
+
+func NewClock(tc TimeControl) Clock {
+	return Clock{TimeControl: tc, Left: tc.Base}
+}
+
+// Available is the longest the next move may take without losing on
+// time.
+func (c Clock) Available() time.Duration {
+	return c.Left + c.Byoyomi
+}
+
+// Running is the clock as it reads d into a move: main time goes first,
+// then byoyomi.
+func (c Clock) Running(d time.Duration) Clock {
+	c.Left -= d
+	if c.Left < 0 {
+		c.Byoyomi = max(c.Byoyomi+c.Left, 0)
+		c.Left = 0
+	}
+	return c
+}
+
+// Spend charges a move that took d, adding the increment. It reports
+// false if the move took longer than was available, leaving the clock at
+// zero.
+func (c *Clock) Spend(d time.Duration) bool {
+	if !c.Limited() {
+		return true
+	}
+	if d > c.Available() {
+		c.Left = 0
+		return false
+	}
+	c.Left = max(c.Left-d, 0) + c.Increment
+	return true
+}
+
+// phaseWeight is how much of its time a player should give a move with
+// empty squares left, relative to other moves. Openings are well trodden
+// and the last dozen squares are solved at once, so the time goes to the
+// midgame and to the endgame before it can be solved.
+func phaseWeight(empty int) time.Duration {
+	switch {
+	case empty > 44:
+		return 1
+	case empty > 24:
+		return 3
+	case empty > EndgameDepth:
+		return 4
+	}
+	return 1
+}
+
+// Budget is the time to spend on a move in b: a share of the main time
+// left weighted by the stage of the game, and most of the increment and
+// byoyomi, which come back after the move. It always leaves a margin
+// before the flag falls. Budget is 0 for a clock without a limit.
+func (c Clock) Budget(b *Board) time.Duration {
+	if !c.Limited() {
+		return 0
+	}
+
+	// The player has about half the empty squares still to fill.
+	empty := bits.OnesCount64(b.Empty())
+	var plan time.Duration
+	for e := empty; e > 0; e -= 2 {
+		plan += phaseWeight(e)
+	}
+	plan = max(plan, 1)
+
+	budget := c.Left*phaseWeight(empty)/plan + (c.Increment+c.Byoyomi)*3/4
+	return max(min(budget, c.Available()*3/4), time.Millisecond)
+}
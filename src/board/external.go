@@ -0,0 +1,206 @@
+package board
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EngineError reports a failure of an external engine: it crashed, ran out
+// of time or answered with a move that is not legal.
+type EngineError struct {
+	Engine string
+	Reason string
+}
+
+func (e *EngineError) Error() string {
+	return fmt.Sprintf("engine %s: %s", e.Engine, e.Reason)
+}
+
+// External is a Player backed by an engine running as a subprocess and
+// speaking the NBoard protocol on stdin/stdout. The process is started on
+// the first move and restarted after a crash or timeout.
+type External struct {
+	Path    string
+	Args    []string
+	Depth   int
+	Timeout time.Duration // per move, 0 means no limit
+
+	// Err holds the reason the last GetMove returned ok == false while the
+	// side to move had a legal move.
+	Err error
+
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	lines chan string
+	ping  int
+}
+
+func NewExternal(path string, depth int, args ...string) *External {
+	return &External{Path: path, Args: args, Depth: depth}
+}
+
+func (e *External) start() error {
+	cmd := exec.Command(e.Path, e.Args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return &EngineError{Engine: e.Path, Reason: err.Error()}
+	}
+
+	lines := make(chan string, 16)
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+		cmd.Wait()
+	}()
+
+	e.cmd, e.stdin, e.lines = cmd, stdin, lines
+	return e.send("nboard 2", fmt.Sprintf("set depth %d", e.Depth))
+}
+
+// Close stops the engine process.
+func (e *External) Close() error {
+	if e.cmd == nil {
+		return nil
+	}
+	if err := e.send("quit"); err != nil {
+		return nil // already dead
+	}
+	e.stdin.Close()
+
+	select {
+	case <-e.drain():
+	case <-time.After(time.Second):
+		e.cmd.Process.Kill()
+	}
+	e.cmd = nil
+	return nil
+}
+
+// drain discards engine output until the process closes stdout.
+func (e *External) drain() <-chan struct{} {
+	done := make(chan struct{})
+	go func(lines <-chan string) {
+		for range lines {
+		}
+		close(done)
+	}(e.lines)
+	return done
+}
+
+// fail kills the engine so the next move starts a fresh process.
+func (e *External) fail(reason string) error {
+	if e.cmd != nil {
+		e.cmd.Process.Kill()
+		e.drain()
+		e.cmd = nil
+	}
+	return &EngineError{Engine: e.Path, Reason: reason}
+}
+
+func (e *External) send(commands ...string) error {
+	for _, c := range commands {
+		if _, err := io.WriteString(e.stdin, c+"\n"); err != nil {
+			return e.fail("write: " + err.Error())
+		}
+	}
+	return nil
+}
+
+// expect waits for a line starting with prefix and returns the rest of it.
+// Other lines (status, nodestats, ...) are ignored.
+func (e *External) expect(prefix string, timeout <-chan time.Time) (string, error) {
+	for {
+		select {
+		case line, ok := <-e.lines:
+			if !ok {
+				return "", e.fail("engine exited")
+			}
+			if strings.HasPrefix(line, prefix) {
+				return strings.TrimSpace(line[len(prefix):]), nil
+			}
+		case <-timeout:
+			return "", e.fail("timeout")
+		}
+	}
+}
+
+// Move asks the engine for its move in position b.
+func (e *External) Move(b *Board) (x, y int, err error) {
+	if e.cmd == nil {
+		if err := e.start(); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	var timeout <-chan time.Time
+	if e.Timeout > 0 {
+		timer := time.NewTimer(e.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	// ping/pong makes sure the engine has read the position before "go".
+	e.ping++
+	if err := e.send("set game "+GGF(*b), "ping "+strconv.Itoa(e.ping)); err != nil {
+		return 0, 0, err
+	}
+	for {
+		pong, err := e.expect("pong", timeout)
+		if err != nil {
+			return 0, 0, err
+		}
+		if pong == strconv.Itoa(e.ping) {
+			break
+		}
+	}
+
+	if err := e.send("go"); err != nil {
+		return 0, 0, err
+	}
+	reply, err := e.expect("===", timeout)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// The reply is "MOVE" or "MOVE/eval/time".
+	move := strings.ToLower(strings.SplitN(reply, "/", 2)[0])
+	x, y, err = ParseSquare(move)
+	if err != nil {
+		return 0, 0, e.fail(fmt.Sprintf("bad reply %q", reply))
+	}
+
+	if b.LegalMoves()&SqureToBit(x, y) == 0 {
+		return 0, 0, e.fail(fmt.Sprintf("illegal move %s", move))
+	}
+	return x, y, nil
+}
+
+func (e *External) GetMove(b *Board) (int, int, bool) {
+	e.Err = nil
+
+	if b.LegalMoves() == 0 {
+		return 0, 0, false
+	}
+
+	x, y, err := e.Move(b)
+	if err != nil {
+		e.Err = err
+		return 0, 0, false
+	}
+	return x, y, true
+}
@@ -0,0 +1,110 @@
+package board
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// engine is the stand-in NBoard engine in ../nboard, built by TestMain.
+var engine string
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "nboard")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	engine = filepath.Join(dir, "nboard")
+	build := exec.Command("go", "build", "-o", engine, "../nboard")
+	build.Stderr = os.Stderr
+	if err := build.Run(); err != nil {
+		fmt.Fprintln(os.Stderr, "building the stand-in engine:", err)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// engineErr is the reason of the EngineError that made e fail a move.
+func engineErr(t *testing.T, e *External) string {
+	t.Helper()
+	var err *EngineError
+	if !errors.As(e.Err, &err) {
+		t.Fatalf("Err = %v, want an EngineError", e.Err)
+	}
+	return err.Reason
+}
+
+func TestExternalPlaysLegalMoves(t *testing.T) {
+	e := NewExternal(engine, 2)
+	defer e.Close()
+
+	b := NewBoard()
+	for i := 0; i < 4; i++ {
+		x, y, ok := e.GetMove(&b)
+		if !ok {
+			t.Fatalf("move %d: %v", i+1, e.Err)
+		}
+		if err := b.PlayXY(x, y); err != nil {
+			t.Fatalf("move %d %s: %v", i+1, SquareName(x, y), err)
+		}
+	}
+}
+
+func TestExternalFaults(t *testing.T) {
+	for _, tc := range []struct {
+		fault, want string
+	}{
+		{"crash", "engine exited"},
+		{"illegal", "illegal move a1"},
+		{"garbage", `bad reply "Z9"`},
+		{"slow", "timeout"},
+	} {
+		t.Run(tc.fault, func(t *testing.T) {
+			e := NewExternal(engine, 2, "-fault="+tc.fault)
+			e.Timeout = 200 * time.Millisecond
+			defer e.Close()
+
+			b := NewBoard()
+			if _, _, ok := e.GetMove(&b); ok {
+				t.Fatal("a faulty engine moved")
+			}
+			if got := engineErr(t, e); got != tc.want {
+				t.Errorf("reason = %q, want %q", got, tc.want)
+			}
+			if e.cmd != nil {
+				t.Errorf("the faulty process was kept")
+			}
+		})
+	}
+}
+
+func TestExternalRestartsAfterKill(t *testing.T) {
+	e := NewExternal(engine, 2)
+	defer e.Close()
+
+	b := NewBoard()
+	if _, _, ok := e.GetMove(&b); !ok {
+		t.Fatal(e.Err)
+	}
+	e.cmd.Process.Kill()
+
+	if _, _, ok := e.GetMove(&b); ok {
+		t.Fatal("moved with the engine killed")
+	}
+	if got := engineErr(t, e); !strings.Contains(got, "exited") && !strings.HasPrefix(got, "write") {
+		t.Errorf("reason = %q", got)
+	}
+
+	if _, _, ok := e.GetMove(&b); !ok {
+		t.Fatalf("no move from the restarted engine: %v", e.Err)
+	}
+}
@@ -0,0 +1,189 @@
+package board
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Game is a board with its history, so moves can be taken back and played
+// again. Passes are not recorded; they happen inside PlayXY.
+type Game struct {
+	positions []Board  // the start, then the position after each move
+	moves     []string // square names, one per position after the start
+	current   int      // index into positions; later ones can be redone
+
+	// Seed is that of the bots' random choices, saved with the game so
+	// that it can be played again; 0 if unknown.
+	Seed int64
+}
+
+func NewGame(start Board) *Game {
+	return &Game{positions: []Board{start}}
+}
+
+// Board returns the current position.
+func (g *Game) Board() Board {
+	return g.positions[g.current]
+}
+
+// Start returns the position the game began from.
+func (g *Game) Start() Board {
+	return g.positions[0]
+}
+
+// Moves returns the moves played to reach the current position.
+func (g *Game) Moves() []string {
+	return g.moves[:g.current]
+}
+
+// Positions returns every position of the game, including those after
+// the current one that Redo would reach.
+func (g *Game) Positions() []Board {
+	return append([]Board(nil), g.positions...)
+}
+
+// Ply returns the index of the current position in Positions.
+func (g *Game) Ply() int {
+	return g.current
+}
+
+// LastMove returns the move that led to the current position.
+func (g *Game) LastMove() (x, y int, ok bool) {
+	if g.current == 0 {
+		return 0, 0, false
+	}
+	x, y, err := ParseSquare(g.moves[g.current-1])
+	return x, y, err == nil
+}
+
+// PlayXY plays a move for the side to move, dropping the moves that could
+// have been redone.
+func (g *Game) PlayXY(x, y int) error {
+	b := g.Board()
+	if err := b.PlayXY(x, y); err != nil {
+		return err
+	}
+
+	g.positions = append(g.positions[:g.current+1], b)
+	g.moves = append(g.moves[:g.current], SquareName(x, y))
+	g.current++
+	return nil
+}
+
+func (g *Game) Play(move string) error {
+	x, y, err := ParseSquare(move)
+	if err != nil {
+		return err
+	}
+	return g.PlayXY(x, y)
+}
+
+func (g *Game) CanUndo() bool { return g.current > 0 }
+func (g *Game) CanRedo() bool { return g.current < len(g.moves) }
+
+// Undo takes back the last move.
+func (g *Game) Undo() bool {
+	if !g.CanUndo() {
+		return false
+	}
+	g.current--
+	return true
+}
+
+// Redo plays again the last move taken back.
+func (g *Game) Redo() bool {
+	if !g.CanRedo() {
+		return false
+	}
+	g.current++
+	return true
+}
+
+// Transcript writes the moves played as one string, such as "c5c6d3".
+func (g *Game) Transcript() string {
+	return strings.Join(g.Moves(), "")
+}
+
+// ParseTranscript replays a move list from the normal start position.
+// Whitespace is ignored, and like LoadXOT it accepts lists written for the
+// standard start position, which is this board's mirrored.
+func ParseTranscript(transcript string) (*Game, error) {
+	transcript = strings.ToLower(strings.Join(strings.Fields(transcript), ""))
+
+	g, err := replay(transcript)
+	if err != nil {
+		if mirrored, merr := replay(mirrorFiles(transcript)); merr == nil {
+			return mirrored, nil
+		}
+	}
+	return g, err
+}
+
+func replay(transcript string) (*Game, error) {
+	if len(transcript)%2 != 0 {
+		return nil, &InvalidMoveError{Code: CodeInvalidFormat, Reason: "invalid format"}
+	}
+
+	g := NewGame(NewBoard())
+	for i := 0; i < len(transcript); i += 2 {
+		if err := g.Play(transcript[i : i+2]); err != nil {
+			return nil, fmt.Errorf("move %d %s: %w", i/2+1, transcript[i:i+2], err)
+		}
+	}
+	return g, nil
+}
+
+// GGF writes the game as a Generic Game Format record: its start position
+// and the moves played. A seed goes in an SD tag of our own, which other
+// readers skip.
+func (g *Game) GGF() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "(;GM[Othello]PC[Othello-Engine]TY[8]BO[8 %s]", ggfBoard(g.Start()))
+	if g.Seed != 0 {
+		fmt.Fprintf(&sb, "SD[%d]", g.Seed)
+	}
+	for i, move := range g.Moves() {
+		tag := "W"
+		if g.positions[i].BlackTurn {
+			tag = "B"
+		}
+		fmt.Fprintf(&sb, "%s[%s]", tag, move)
+	}
+	sb.WriteString(";)")
+	return sb.String()
+}
+
+// ParseRecord reads a saved game: a GGF record, or else a transcript, in
+// which text from '#' to the end of a line is a comment.
+func ParseRecord(record string) (*Game, error) {
+	if strings.Contains(record, "(;") {
+		return ParseGGFGame(record)
+	}
+
+	var seed int64
+	lines := strings.Split(record, "\n")
+	for i, line := range lines {
+		moves, comment, _ := strings.Cut(line, "#")
+		lines[i] = moves
+		fmt.Sscanf(strings.TrimSpace(comment), "seed %d", &seed)
+	}
+	g, err := ParseTranscript(strings.Join(lines, "\n"))
+	if err != nil {
+		return nil, err
+	}
+	g.Seed = seed
+	return g, nil
+}
+
+// FormatRecord writes g as GGF for a file name ending in ".ggf" and as a
+// transcript otherwise, after a "# seed" comment if the seed is known.
+func FormatRecord(g *Game, name string) string {
+	if strings.EqualFold(filepath.Ext(name), ".ggf") {
+		return g.GGF() + "\n"
+	}
+	if g.Seed != 0 {
+		return fmt.Sprintf("# seed %d\n%s\n", g.Seed, g.Transcript())
+	}
+	return g.Transcript() + "\n"
+}
@@ -0,0 +1,155 @@
+package board
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// GGF writes b as a Generic Game Format record with no moves. This is the
+// form the NBoard protocol uses to hand positions to an engine.
+func GGF(b Board) string {
+	return fmt.Sprintf("(;GM[Othello]PC[Othello-Engine]TY[8]BO[8 %s];)", ggfBoard(b))
+}
+
+// Position writes b as 64 squares, a1 to h8, using '-', '*' for black and
+// 'O' for white, then a space and the side to move ('*' or 'O').
+func Position(b Board) string {
+	return ggfBoard(b)
+}
+
+// ParsePosition reads a position written by Position. Whitespace between
+// squares is ignored, and 'X' and '.' may stand for '*' and '-'.
+func ParsePosition(s string) (Board, error) {
+	return parseGGFBoard("8 " + s)
+}
+
+// ggfBoard renders the squares a1, b1, ... h8 followed by the side to move.
+func ggfBoard(b Board) string {
+	var sb strings.Builder
+	for y := 0; y < 8; y++ {
+		for x := 7; x >= 0; x-- {
+			bit := SqureToBit(x, y)
+			switch {
+			case b.Black&bit != 0:
+				sb.WriteByte('*')
+			case b.White&bit != 0:
+				sb.WriteByte('O')
+			default:
+				sb.WriteByte('-')
+			}
+		}
+	}
+
+	if b.BlackTurn {
+		sb.WriteString(" *")
+	} else {
+		sb.WriteString(" O")
+	}
+	return sb.String()
+}
+
+// ParseGGF reads the starting position (BO) and the moves (B, W) of a GGF
+// game record and returns the position after the last move.
+func ParseGGF(record string) (Board, error) {
+	g, err := ParseGGFGame(record)
+	if err != nil {
+		return Board{}, err
+	}
+	return g.Board(), nil
+}
+
+// ParseGGFGame is ParseGGF keeping the moves.
+func ParseGGFGame(record string) (*Game, error) {
+	var g *Game
+	var seed int64
+
+	for rest := record; ; {
+		open := strings.IndexByte(rest, '[')
+		if open < 0 {
+			break
+		}
+		end := strings.IndexByte(rest[open:], ']')
+		if end < 0 {
+			return nil, fmt.Errorf("ggf: unterminated tag")
+		}
+
+		tag := rest[:open]
+		tag = tag[strings.LastIndexAny(tag, "; \t\r\n()")+1:]
+		value := rest[open+1 : open+end]
+		rest = rest[open+end+1:]
+
+		switch tag {
+		case "BO":
+			start, err := parseGGFBoard(value)
+			if err != nil {
+				return nil, err
+			}
+			g = NewGame(start)
+		case "SD":
+			var err error
+			if seed, err = strconv.ParseInt(value, 10, 64); err != nil {
+				return nil, fmt.Errorf("ggf: bad seed %q", value)
+			}
+		case "B", "W":
+			if g == nil {
+				return nil, fmt.Errorf("ggf: move before board")
+			}
+			move := strings.ToLower(strings.SplitN(value, "/", 2)[0])
+			if move == "pa" || move == "pass" {
+				continue
+			}
+			x, y, err := ParseSquare(move)
+			if err != nil {
+				return nil, fmt.Errorf("ggf: %w", err)
+			}
+			// A side moving out of turn follows a pass left unrecorded.
+			g.positions[g.current].BlackTurn = tag == "B"
+			if err := g.PlayXY(x, y); err != nil {
+				return nil, fmt.Errorf("ggf: %s %s: %w", tag, move, err)
+			}
+		}
+	}
+
+	if g == nil {
+		return nil, fmt.Errorf("ggf: no board")
+	}
+	g.Seed = seed
+	return g, nil
+}
+
+func parseGGFBoard(value string) (Board, error) {
+	fields := strings.Fields(value)
+	if len(fields) < 2 || fields[0] != "8" {
+		return Board{}, fmt.Errorf("ggf: unsupported board %q", value)
+	}
+
+	squares := strings.Join(fields[1:], "")
+	if len(squares) != 65 {
+		return Board{}, fmt.Errorf("ggf: bad board %q", value)
+	}
+
+	var b Board
+	for i := 0; i < 64; i++ {
+		bit := SqureToBit(7-i%8, i/8)
+		switch squares[i] {
+		case '*', 'X', 'x':
+			b.Black |= bit
+		case 'O', 'o':
+			b.White |= bit
+		case '-', '.':
+		default:
+			return Board{}, fmt.Errorf("ggf: bad square %q", squares[i])
+		}
+	}
+
+	switch squares[64] {
+	case '*', 'X', 'x':
+		b.BlackTurn = true
+	case 'O', 'o':
+		b.BlackTurn = false
+	default:
+		return Board{}, fmt.Errorf("ggf: bad side to move %q", squares[64])
+	}
+	return b, nil
+}
@@ -0,0 +1,30 @@
+package board
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHumanPlayer(t *testing.T) {
+	var out strings.Builder
+	h := &HumanPlayer{In: strings.NewReader("zz\na1\ne3\nd3\n"), Out: &out}
+
+	b := NewBoard()
+	x, y, ok := h.GetMove(&b)
+	if !ok || SquareName(x, y) != "e3" {
+		t.Fatalf("first move = %s, %v", SquareName(x, y), ok)
+	}
+	if !strings.Contains(out.String(), "Invalid input.") || !strings.Contains(out.String(), "Illegal move.") {
+		t.Errorf("bad moves not reported:\n%s", out.String())
+	}
+
+	// The next move comes from input read ahead for the first.
+	b.PlayXY(x, y)
+	x, y, ok = h.GetMove(&b)
+	if !ok || SquareName(x, y) != "d3" {
+		t.Errorf("second move = %s, %v", SquareName(x, y), ok)
+	}
+	if _, _, ok := h.GetMove(&b); ok {
+		t.Error("move read after the input ended")
+	}
+}
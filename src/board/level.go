@@ -0,0 +1,43 @@
+package board
+
+import "fmt"
+
+// Level is a playing strength for casual games: a search limit, and how
+// far the bot's choice strays from the move it found best. See Bot.
+type Level struct {
+	Depth   int
+	Nodes   int // positions searched per move, 0 for no limit
+	Noise   int
+	Blunder float64
+}
+
+// Levels run from level 1, Levels[0], to MaxLevel, which is plain
+// pengwin at depth 8. Each was tuned against the one below, aiming at steps
+// of about 200 Elo; the README has the commands run and the results.
+// Whole plies make uneven steps, so levels 7 to 9 also limit the positions
+// searched, which lands them between depths.
+var Levels = [...]Level{
+	{Depth: 1, Noise: 6, Blunder: 0.3},
+	{Depth: 1},
+	{Depth: 2, Noise: 1, Blunder: 0.1},
+	{Depth: 3, Noise: 1, Blunder: 0.05},
+	{Depth: 4, Noise: 2, Blunder: 0.1},
+	{Depth: 4},
+	{Depth: 5, Nodes: 15000},
+	{Depth: 6, Nodes: 150000},
+	{Depth: 7, Nodes: 300000},
+	{Depth: 8},
+}
+
+const MaxLevel = len(Levels)
+
+// NewLevel returns a Pengwin playing side at a level from 1 to MaxLevel.
+func NewLevel(level int, side string) (Pengwin, error) {
+	if level < 1 || level > MaxLevel {
+		return Pengwin{}, fmt.Errorf("level must be between 1 and %d", MaxLevel)
+	}
+	l := Levels[level-1]
+	p := NewPengwin(l.Depth, side)
+	p.Nodes, p.Noise, p.Blunder = l.Nodes, l.Noise, l.Blunder
+	return p, nil
+}
@@ -0,0 +1,148 @@
+package board
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+)
+
+// Opening is a start position together with the moves that led to it.
+type Opening struct {
+	Moves string
+	Board Board
+}
+
+// ParseOpening replays a move list such as "f5d6c3" or "F5 D6 C3" from
+// the start position.
+func ParseOpening(moves string) (Opening, error) {
+	moves = strings.ToLower(strings.Join(strings.Fields(moves), ""))
+	if len(moves)%2 != 0 {
+		return Opening{}, &InvalidMoveError{Code: CodeInvalidFormat, Reason: "invalid format"}
+	}
+
+	b := NewBoard()
+	for i := 0; i < len(moves); i += 2 {
+		if err := b.Play(moves[i : i+2]); err != nil {
+			return Opening{}, fmt.Errorf("%s: %w", moves[i:i+2], err)
+		}
+	}
+	return Opening{Moves: moves, Board: b}, nil
+}
+
+// RandomOpening plays plies random moves, drawn from r, from the start
+// position. It may stop early if the game ends.
+func RandomOpening(r *rand.Rand, plies int) Opening {
+	b := NewBoard()
+	var moves strings.Builder
+	for i := 0; i < plies && !b.GameOver(); i++ {
+		x, y, _ := RandomPlayer{Rand: r}.GetMove(&b)
+		b.PlayXY(x, y)
+		moves.WriteString(SquareName(x, y))
+	}
+	return Opening{Moves: moves.String(), Board: b}
+}
+
+// maxDraws is how many random openings BalancedOpening and
+// BalancedOpenings draw in a row without finding one they want before
+// giving up.
+const maxDraws = 1000
+
+// BalancedOpening draws random openings of the given length from r until
+// Pengwin, searching depth plies, scores one within margin of even.
+func BalancedOpening(r *rand.Rand, plies, depth, margin int) (Opening, error) {
+	if plies < 1 {
+		return Opening{}, fmt.Errorf("openings need at least one ply, not %d", plies)
+	}
+	for range maxDraws {
+		o := RandomOpening(r, plies)
+		if !o.Board.GameOver() && o.Balanced(depth, margin) {
+			return o, nil
+		}
+	}
+	return Opening{}, fmt.Errorf("no balanced opening of %d plies in %d draws", plies, maxDraws)
+}
+
+// Balanced reports whether a search of the opening position scores it
+// within margin of zero.
+func (o Opening) Balanced(depth, margin int) bool {
+	b := o.Board
+	player, opponent := b.White, b.Black
+	if b.BlackTurn {
+		player, opponent = b.Black, b.White
+	}
+
+	score := Pengwin{}.Score(player, opponent, depth)
+	return -margin <= score && score <= margin
+}
+
+// BalancedOpenings returns n different balanced openings. If there are
+// fewer to be found, it returns those it found with an error.
+func BalancedOpenings(r *rand.Rand, n, plies, depth, margin int) ([]Opening, error) {
+	if plies < 1 {
+		return nil, fmt.Errorf("openings need at least one ply, not %d", plies)
+	}
+
+	seen := make(map[Board]bool)
+	openings := make([]Opening, 0, n)
+	for misses := 0; len(openings) < n; {
+		o := RandomOpening(r, plies)
+		if seen[o.Board] || o.Board.GameOver() || !o.Balanced(depth, margin) {
+			if misses++; misses == maxDraws {
+				return openings, fmt.Errorf("found only %d of %d balanced openings of %d plies", len(openings), n, plies)
+			}
+			continue
+		}
+		misses = 0
+		seen[o.Board] = true
+		openings = append(openings, o)
+	}
+	return openings, nil
+}
+
+// mirrorFiles swaps files a-h, b-g, ... in a move list. The standard start
+// position is this board's start position mirrored that way.
+func mirrorFiles(moves string) string {
+	mirrored := []byte(strings.ToLower(moves))
+	for i, c := range mirrored {
+		if 'a' <= c && c <= 'h' {
+			mirrored[i] = 'a' + 'h' - c
+		}
+	}
+	return string(mirrored)
+}
+
+// LoadXOT reads an opening list in the XOT format: one move list per line.
+// Blank lines and lines starting with '#' are skipped. Published lists use
+// the standard start position, so a line that does not replay as written is
+// replayed with its files mirrored.
+func LoadXOT(r io.Reader) ([]Opening, error) {
+	var openings []Opening
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		o, err := ParseOpening(text)
+		if err != nil {
+			o, err = ParseOpening(mirrorFiles(text))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("xot line %d: %w", line, err)
+		}
+		openings = append(openings, o)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(openings) == 0 {
+		return nil, errors.New("no openings in the xot list")
+	}
+	return openings, nil
+}
@@ -0,0 +1,81 @@
+package board
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadXOT(t *testing.T) {
+	list := `# standard notation, replayed mirrored
+f5d6c3
+
+# this board's own notation
+e3d3c5 f3
+F4 F3
+`
+	openings, err := LoadXOT(strings.NewReader(list))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"c5e6f3", "e3d3c5f3", "f4f3"}
+	if len(openings) != len(want) {
+		t.Fatalf("read %d openings, want %d", len(openings), len(want))
+	}
+	for i, o := range openings {
+		if o.Moves != want[i] {
+			t.Errorf("opening %d = %s, want %s", i+1, o.Moves, want[i])
+		}
+		if replayed, err := ParseOpening(o.Moves); err != nil || replayed.Board != o.Board {
+			t.Errorf("opening %d does not replay: %v", i+1, err)
+		}
+	}
+}
+
+func TestLoadXOTRejects(t *testing.T) {
+	for _, tc := range []struct {
+		list, want string
+	}{
+		{"e3d3\na1a2\n", "xot line 2"},
+		{"e3d\n", "xot line 1"},
+		{"", "no openings"},
+		{"# only a comment\n\n", "no openings"},
+	} {
+		_, err := LoadXOT(strings.NewReader(tc.list))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("LoadXOT(%q) = %v, want %q", tc.list, err, tc.want)
+		}
+	}
+}
+
+func TestBalancedOpenings(t *testing.T) {
+	openings, err := BalancedOpenings(NewRand(1), 8, 6, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[Board]bool)
+	for _, o := range openings {
+		if seen[o.Board] {
+			t.Errorf("%s repeats a position", o.Moves)
+		}
+		seen[o.Board] = true
+		if len(o.Moves) != 12 || !o.Balanced(2, 2) {
+			t.Errorf("%s is not a balanced 6-ply opening", o.Moves)
+		}
+	}
+}
+
+func TestBalancedOpeningsRunDry(t *testing.T) {
+	// There are only four positions after one ply.
+	openings, err := BalancedOpenings(NewRand(1), 10, 1, 1, 100)
+	if err == nil || len(openings) != 4 {
+		t.Errorf("got %d openings and %v, want 4 and an error", len(openings), err)
+	}
+
+	if _, err := BalancedOpenings(NewRand(1), 4, 0, 1, 100); err == nil {
+		t.Errorf("openings of no plies accepted")
+	}
+	if _, err := BalancedOpening(NewRand(1), 0, 1, 100); err == nil {
+		t.Errorf("an opening of no plies accepted")
+	}
+}
@@ -0,0 +1,35 @@
+package board
+
+// Perft counts the lines of play depth plies long from b, the usual check
+// that move generation is right. A pass counts as a ply, and a game that
+// ends sooner counts as one line.
+func Perft(b Board, depth int) uint64 {
+	player, opponent := b.White, b.Black
+	if b.BlackTurn {
+		player, opponent = b.Black, b.White
+	}
+	return perft(player, opponent, depth, false)
+}
+
+func perft(player, opponent uint64, depth int, passed bool) uint64 {
+	if depth == 0 {
+		return 1
+	}
+
+	moves := Moves(player, opponent)
+	if moves == 0 {
+		if passed {
+			return 1
+		}
+		return perft(opponent, player, depth-1, true)
+	}
+
+	var n uint64
+	for moves != 0 {
+		move := moves & -moves
+		moves ^= move
+		flips := flip(player, opponent, move)
+		n += perft(opponent&^flips, player|move|flips, depth-1, false)
+	}
+	return n
+}
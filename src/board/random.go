@@ -0,0 +1,36 @@
+package board
+
+import "math/rand"
+
+// Random choices, by bots and in openings, come from a *rand.Rand so that
+// a game can be played again exactly from its seed. A nil *rand.Rand uses
+// the global source, which differs from run to run. That is left to games
+// nobody replays: the desktop app's bots, whose stopped searches may still
+// be drawing when the next one starts and so need a source safe to share,
+// the nboard stand-in engine, and zero values such as RandomPlayer{} in
+// tests. Games that record a seed always pass a source.
+
+// NewRand returns a source of random choices that repeats for the same
+// seed. It is not safe for concurrent use.
+func NewRand(seed int64) *rand.Rand {
+	return rand.New(rand.NewSource(seed))
+}
+
+// NewSeed picks a seed for a game that was not given one.
+func NewSeed() int64 {
+	return rand.Int63()
+}
+
+func intn(r *rand.Rand, n int) int {
+	if r == nil {
+		return rand.Intn(n)
+	}
+	return r.Intn(n)
+}
+
+func float64From(r *rand.Rand) float64 {
+	if r == nil {
+		return rand.Float64()
+	}
+	return r.Float64()
+}
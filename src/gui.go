@@ -0,0 +1,11 @@
+//go:build gui
+
+package main
+
+import "Othello-Engine/ui"
+
+func gui(args []string) error {
+	newFlagSet("gui", "").Parse(args)
+	ui.LaunchGame()
+	return nil
+}
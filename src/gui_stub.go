@@ -0,0 +1,11 @@
+//go:build !gui
+
+package main
+
+import "errors"
+
+// The desktop app needs OpenGL and a display, so it is only built with the
+// gui tag; without it the binary runs anywhere, as a server.
+func gui(args []string) error {
+	return errors.New("built without the desktop app; rebuild with -tags gui")
+}
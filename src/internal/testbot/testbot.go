@@ -0,0 +1,13 @@
+// Package testbot has players for tests that need to control a bot.
+package testbot
+
+import "Othello-Engine/board"
+
+// Gate plays a random move each time it receives from its channel, so a
+// test decides when the bot's turn ends.
+type Gate chan struct{}
+
+func (g Gate) GetMove(b *board.Board) (int, int, bool) {
+	<-g
+	return board.RandomPlayer{}.GetMove(b)
+}
@@ -1,85 +1,96 @@
+// Othello-Engine plays Othello in the browser, on the desktop or in the
+// terminal, and has tools for testing and studying the engine. Each mode
+// is a command with its own flags:
+//
+//	othello serve -addr :8080
+//	othello play --black=human --white=pengwin:8
+//	othello analyze -depth 10 f5d6c3d3c4
+//
+// With no command, or only flags, it serves, as it did before it had
+// commands.
 package main
 
 import (
-	"Othello-Engine/board"
-	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
-	"math/bits"
-	"net/http"
+	"os"
 	"strings"
 )
 
 const depth int = 8
 
-var game board.Board = board.NewBoard()
-var botPlayer board.Pengwin = board.NewPengwin(depth, "white")
-
-type MoveRequest struct {
-	Move string `json:"move"`
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
 }
 
-type BoardResponse struct {
-	Black     string `json:"black"`
-	White     string `json:"white"`
-	BlackTurn bool   `json:"black_turn"`
+var commands = []command{
+	{"serve", "serve the web client and the game API", serve},
+	{"gui", "open the desktop app (built with -tags gui)", gui},
+	{"play", "play a game in the terminal", play},
+	{"selfplay", "play bots against each other and score them", selfplay},
+	{"perft", "count the lines of play from a position", perft},
+	{"bench", "time searches of a fixed set of positions", bench},
+	{"solve", "solve a position exactly", solve},
+	{"analyze", "score every move in a position", analyze},
 }
 
-func moveHandler(w http.ResponseWriter, r *http.Request) {
-	var req MoveRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request", http.StatusBadRequest)
-		return
+// usageError is a mistake in a command's arguments; like a bad flag, it
+// exits with status 2.
+type usageError struct{ error }
+
+func main() {
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
 	}
 
-	if err := game.Play(req.Move); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if name == "help" {
+		usage(os.Stdout)
 		return
 	}
-
-	resp := BoardResponse{
-		Black:     fmt.Sprintf("%d", game.Black),
-		White:     fmt.Sprintf("%d", game.White),
-		BlackTurn: game.BlackTurn,
+	for _, c := range commands {
+		if c.name != name {
+			continue
+		}
+		err := c.run(args)
+		var uerr usageError
+		switch {
+		case errors.As(err, &uerr):
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		case err != nil:
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
 	}
-	json.NewEncoder(w).Encode(resp)
 
-	if !game.BlackTurn {
-		go func() {
-			x, y, ok := botPlayer.GetMove(&game)
-			if ok {
-				game.PlayXY(x, y)
-			}
-		}()
-	}
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+	usage(os.Stderr)
+	os.Exit(2)
 }
 
-func stateHandler(w http.ResponseWriter, r *http.Request) {
-	resp := BoardResponse{
-		Black:     fmt.Sprintf("%d", game.Black),
-		White:     fmt.Sprintf("%d", game.White),
-		BlackTurn: game.BlackTurn,
+func usage(w *os.File) {
+	fmt.Fprintln(w, "usage: othello <command> [flags]")
+	fmt.Fprintln(w)
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-9s %s\n", c.name, c.summary)
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Run "othello <command> -h" for a command's flags.`)
 }
 
-func main() {
-	// Choose player types
-	// black := board.NewPengwin(6, "black")
-	// white := board.NewGreedy(depth, "white")
-	// white := board.HumanPlayer{}
-
-	// RunGame(black, white)
-	// ui.LaunchGame()
-
-	fs := http.FileServer(http.Dir("./static"))
-	http.Handle("/", fs)
-
-	http.HandleFunc("/move", moveHandler)
-	http.HandleFunc("/state", stateHandler)
-
-	println("Server started at http://localhost:8080")
-	http.ListenAndServe(":8080", nil)
+// newFlagSet makes the flags of a command.
+func newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: othello %s [flags] %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
 }
 
 func format(n uint64) string {
@@ -90,62 +101,3 @@ func format(n uint64) string {
 	}
 	return strings.Join(parts, "_")
 }
-
-func RunGame(black, white board.Player) {
-	b := board.NewBoard()
-
-	// test
-	// diff := false
-
-	for !b.GameOver() {
-		b.DisplayBoard()
-
-		var x, y int
-		var ok bool
-
-		if b.BlackTurn {
-			fmt.Println("Black (○) move")
-			x, y, ok = black.GetMove(&b)
-
-			// test
-			// diff = diff || board.TestAlpha(b.Black, b.White, depth)
-		} else {
-			fmt.Println("White (●) move")
-			x, y, ok = white.GetMove(&b)
-
-			// test
-			// diff = diff || board.TestAlpha(b.White, b.Black, depth)
-		}
-
-		if ok {
-			if err := b.PlayXY(x, y); err != nil {
-				fmt.Println("Move error:", err)
-			}
-		} else {
-			fmt.Println("Passing turn.")
-		}
-	}
-
-	b.DisplayBoard()
-	blackCount := bits.OnesCount64(b.Black)
-	whiteCount := bits.OnesCount64(b.White)
-
-	fmt.Println("--------------- Final Result ---------------")
-	fmt.Printf("Black (○): %d\n", blackCount)
-	fmt.Printf("White (●): %d\n", whiteCount)
-
-	switch {
-	case blackCount > whiteCount:
-		fmt.Println("Winner: Black (○)")
-	case whiteCount > blackCount:
-		fmt.Println("Winner: White (●)")
-	default:
-		fmt.Println("Result: Draw")
-	}
-
-	// if diff {
-	// 	fmt.Println("Minimax != AlphaBeta")
-	// } else {
-	// 	fmt.Println("Minimax = AlphaBeta")
-	// }
-}
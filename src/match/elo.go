@@ -0,0 +1,47 @@
+package match
+
+import "math"
+
+// Elo converts a score fraction into an Elo difference.
+func Elo(score float64) float64 {
+	score = math.Min(math.Max(score, 1e-6), 1-1e-6)
+	return -400 * math.Log10(1/score-1)
+}
+
+// EloInterval estimates the Elo difference from per-game scores (1, 0.5 or
+// 0) and returns it with the half-width of its 95% confidence interval.
+// Without games the margin is infinite.
+func EloInterval(scores []float64) (elo, margin float64) {
+	n := float64(len(scores))
+	if n == 0 {
+		return 0, math.Inf(1)
+	}
+
+	var mean float64
+	for _, s := range scores {
+		mean += s
+	}
+	mean /= n
+
+	var variance float64
+	for _, s := range scores {
+		variance += (s - mean) * (s - mean)
+	}
+	variance /= n
+
+	// A clean sweep only says the gap is large, and has no spread to
+	// measure. As in LLR, the outcome that has not happened counts as half
+	// a game, so the estimate leans the sweep's way and stays finite.
+	if mean == 0 || mean == 1 {
+		score := mean*n + 0.5*(1-mean)
+		n += 0.5
+		mean = score / n
+		variance = mean * (1 - mean)
+	}
+	stderr := math.Sqrt(variance) / math.Sqrt(n)
+
+	elo = Elo(mean) + 0 // no "-0" in tables
+	low := Elo(mean - 1.96*stderr)
+	high := Elo(mean + 1.96*stderr)
+	return elo, (high - low) / 2
+}
@@ -0,0 +1,208 @@
+// Package match plays bots against each other: single games, tournaments
+// and the statistics used to compare them.
+package match
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"Othello-Engine/board"
+)
+
+// ErrTimeout is the reason of a player that forfeits by running out of
+// time.
+var ErrTimeout = errors.New("out of time")
+
+// ForfeitError reports a player that lost the game by failing to make a
+// legal move.
+type ForfeitError struct {
+	Black  bool
+	Reason error
+}
+
+func (e *ForfeitError) Error() string {
+	return fmt.Sprintf("%s forfeits: %v", sideName(e.Black), e.Reason)
+}
+
+func (e *ForfeitError) Unwrap() error {
+	return e.Reason
+}
+
+func sideName(black bool) string {
+	if black {
+		return "black"
+	}
+	return "white"
+}
+
+// Game is a game to be played between two players.
+type Game struct {
+	Black, White board.Player
+	Start        *board.Board // nil for the normal start
+
+	BlackTime, WhiteTime board.TimeControl
+
+	// Out, if set, is shown every position and the result.
+	Out io.Writer
+}
+
+// Result is how a game went.
+type Result struct {
+	Moves  []string    // the moves played after the start, without passes
+	Passes int         // turns a side had to pass
+	Final  board.Board // the position the game ended in
+
+	BlackDiscs, WhiteDiscs int
+	Winner                 string // "black", "white", or empty for a draw
+
+	BlackUsed, WhiteUsed time.Duration // thinking time of each side
+
+	// Forfeit is the side that lost by running out of time, failing to
+	// move or playing an illegal move; nil if the game was played out.
+	Forfeit *ForfeitError
+}
+
+// BlackScore is 1 for a black win, 0.5 for a draw and 0 for a loss.
+func (r Result) BlackScore() float64 {
+	switch r.Winner {
+	case "black":
+		return 1
+	case "white":
+		return 0
+	}
+	return 0.5
+}
+
+// Run plays the game. A player forfeits if it has a legal move but returns
+// ok == false, plays an illegal move or overruns its time; the game then
+// ends in the position reached. A TimedPlayer is given its clock to plan
+// its time.
+//
+// A ContextPlayer's search is stopped when its time runs out or ctx is
+// done, the side to move forfeiting in either case. Other players are
+// only checked once they return, so a slow one loses when it moves rather
+// than when its time is up.
+func (g Game) Run(ctx context.Context) Result {
+	b := board.NewBoard()
+	if g.Start != nil {
+		b = *g.Start
+	}
+
+	var r Result
+	clocks := [2]board.Clock{board.NewClock(g.BlackTime), board.NewClock(g.WhiteTime)}
+	for !b.GameOver() {
+		if b.LegalMoves() == 0 {
+			b.BlackTurn = !b.BlackTurn
+			r.Passes++
+			continue
+		}
+		g.show(b)
+
+		player, side := g.White, 1
+		if b.BlackTurn {
+			player, side = g.Black, 0
+		}
+
+		clock := clocks[side]
+		moveCtx, cancel := ctx, context.CancelFunc(func() {})
+		if clock.Limited() {
+			moveCtx, cancel = context.WithTimeout(ctx, clock.Available())
+		}
+		start := time.Now()
+		position := b
+		x, y, ok := board.GetMoveTimed(moveCtx, player, &position, clock)
+		used := time.Since(start)
+		cancel()
+
+		if side == 0 {
+			r.BlackUsed += used
+		} else {
+			r.WhiteUsed += used
+		}
+		if !clocks[side].Spend(used) {
+			r.Forfeit = &ForfeitError{Black: b.BlackTurn, Reason: ErrTimeout}
+			break
+		}
+
+		if !ok {
+			reason := playerErr(player)
+			if ctx.Err() != nil {
+				reason = ctx.Err()
+			}
+			r.Forfeit = &ForfeitError{Black: b.BlackTurn, Reason: reason}
+			break
+		}
+		if err := b.PlayXY(x, y); err != nil {
+			r.Forfeit = &ForfeitError{Black: b.BlackTurn, Reason: fmt.Errorf("%s: %w", board.SquareName(x, y), err)}
+			break
+		}
+		r.Moves = append(r.Moves, board.SquareName(x, y))
+	}
+
+	r.Final = b
+	r.BlackDiscs, r.WhiteDiscs = b.Count()
+	switch {
+	case r.Forfeit != nil:
+		r.Winner = sideName(!r.Forfeit.Black)
+	case r.BlackDiscs > r.WhiteDiscs:
+		r.Winner = "black"
+	case r.WhiteDiscs > r.BlackDiscs:
+		r.Winner = "white"
+	}
+	g.showResult(r)
+	return r
+}
+
+func (g Game) show(b board.Board) {
+	if g.Out == nil {
+		return
+	}
+	b.Draw(g.Out, false, 0, 0)
+	if b.BlackTurn {
+		fmt.Fprintln(g.Out, "Black (○) move")
+	} else {
+		fmt.Fprintln(g.Out, "White (●) move")
+	}
+}
+
+func (g Game) showResult(r Result) {
+	if g.Out == nil {
+		return
+	}
+	r.Final.Draw(g.Out, false, 0, 0)
+	if r.Forfeit != nil {
+		fmt.Fprintln(g.Out, "Move error:", r.Forfeit)
+	}
+
+	fmt.Fprintln(g.Out, "--------------- Final Result ---------------")
+	fmt.Fprintf(g.Out, "Black (○): %d\n", r.BlackDiscs)
+	fmt.Fprintf(g.Out, "White (●): %d\n", r.WhiteDiscs)
+	switch r.Winner {
+	case "black":
+		fmt.Fprintln(g.Out, "Winner: Black (○)")
+	case "white":
+		fmt.Fprintln(g.Out, "Winner: White (●)")
+	default:
+		fmt.Fprintln(g.Out, "Result: Draw")
+	}
+	if g.BlackTime.Limited() || g.WhiteTime.Limited() {
+		fmt.Fprintf(g.Out, "Time: black %v, white %v\n", r.BlackUsed.Round(time.Millisecond), r.WhiteUsed.Round(time.Millisecond))
+	}
+}
+
+// playerErr digs out the reason a player failed to move, if it keeps one.
+func playerErr(p board.Player) error {
+	if e, ok := p.(*board.External); ok && e.Err != nil {
+		return e.Err
+	}
+	return fmt.Errorf("no move")
+}
+
+func closePlayer(p board.Player) {
+	if c, ok := p.(io.Closer); ok {
+		c.Close()
+	}
+}
@@ -0,0 +1,187 @@
+package match
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"Othello-Engine/board"
+)
+
+// script plays its moves in order.
+type script []string
+
+func (s *script) GetMove(*board.Board) (int, int, bool) {
+	if len(*s) == 0 {
+		return 0, 0, false
+	}
+	x, y, err := board.ParseSquare((*s)[0])
+	*s = (*s)[1:]
+	return x, y, err == nil
+}
+
+// slow waits before moving, or until its context is done.
+type slow time.Duration
+
+func (s slow) GetMove(b *board.Board) (int, int, bool) {
+	return s.GetMoveContext(context.Background(), b)
+}
+
+func (s slow) GetMoveContext(ctx context.Context, b *board.Board) (int, int, bool) {
+	select {
+	case <-time.After(time.Duration(s)):
+		return board.RandomPlayer{}.GetMove(b)
+	case <-ctx.Done():
+		return 0, 0, false
+	}
+}
+
+func TestRunPlaysOut(t *testing.T) {
+	var out strings.Builder
+	r := Game{Black: board.NewGreedy(1, "black"), White: board.NewPengwin(1, "white"), Out: &out}.Run(context.Background())
+
+	if r.Forfeit != nil {
+		t.Fatalf("forfeit: %v", r.Forfeit)
+	}
+	if !r.Final.GameOver() {
+		t.Error("game not over")
+	}
+	g, err := board.ParseTranscript(strings.Join(r.Moves, ""))
+	if err != nil {
+		t.Fatalf("moves %v: %v", r.Moves, err)
+	}
+	if g.Board() != r.Final {
+		t.Error("moves do not lead to the final position")
+	}
+	if black, white := r.Final.Count(); black != r.BlackDiscs || white != r.WhiteDiscs {
+		t.Errorf("discs %d-%d, final position has %d-%d", r.BlackDiscs, r.WhiteDiscs, black, white)
+	}
+	if !strings.Contains(out.String(), "Final Result") {
+		t.Errorf("no result shown:\n%s", out.String())
+	}
+}
+
+func TestRunFromStart(t *testing.T) {
+	g, err := board.ParseTranscript("e3f3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := g.Board()
+	black := script{"g3"}
+	white := script{}
+	r := Game{Black: &black, White: &white, Start: &start}.Run(context.Background())
+
+	if len(r.Moves) != 1 || r.Moves[0] != "g3" {
+		t.Errorf("moves = %v, want [g3]", r.Moves)
+	}
+	if r.Forfeit == nil || r.Forfeit.Black || r.Winner != "black" {
+		t.Errorf("forfeit %v, winner %q; want white to forfeit", r.Forfeit, r.Winner)
+	}
+}
+
+func TestRunIllegalMove(t *testing.T) {
+	black := script{"a1"}
+	r := Game{Black: &black, White: board.RandomPlayer{}}.Run(context.Background())
+
+	if r.Forfeit == nil || !r.Forfeit.Black {
+		t.Fatalf("forfeit = %v, want black", r.Forfeit)
+	}
+	if r.Winner != "white" || r.BlackScore() != 0 {
+		t.Errorf("winner %q, black score %v", r.Winner, r.BlackScore())
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	r := Game{
+		Black:     slow(time.Hour),
+		White:     board.RandomPlayer{},
+		BlackTime: board.TimeControl{Base: 20 * time.Millisecond},
+	}.Run(context.Background())
+
+	if r.Forfeit == nil || !r.Forfeit.Black || !errors.Is(r.Forfeit, ErrTimeout) {
+		t.Fatalf("forfeit = %v, want black out of time", r.Forfeit)
+	}
+	if r.BlackUsed < 20*time.Millisecond {
+		t.Errorf("black used %v", r.BlackUsed)
+	}
+}
+
+func TestRunIncrement(t *testing.T) {
+	// Black needs more than its starting time for the game, but each move
+	// gains more than it spends.
+	r := Game{
+		Black:     slow(5 * time.Millisecond),
+		White:     board.RandomPlayer{},
+		BlackTime: board.TimeControl{Base: 50 * time.Millisecond, Increment: 20 * time.Millisecond},
+	}.Run(context.Background())
+
+	if r.Forfeit != nil {
+		t.Fatalf("forfeit: %v after %v", r.Forfeit, r.BlackUsed)
+	}
+	if r.BlackUsed < 50*time.Millisecond {
+		t.Errorf("black used %v, want more than its starting time", r.BlackUsed)
+	}
+}
+
+func TestRunByoyomi(t *testing.T) {
+	// With no main time, every move has the byoyomi to itself.
+	r := Game{
+		Black:     slow(5 * time.Millisecond),
+		White:     board.RandomPlayer{},
+		BlackTime: board.TimeControl{Byoyomi: 30 * time.Millisecond},
+	}.Run(context.Background())
+	if r.Forfeit != nil {
+		t.Fatalf("forfeit: %v", r.Forfeit)
+	}
+
+	r = Game{
+		Black:     slow(time.Hour),
+		White:     board.RandomPlayer{},
+		BlackTime: board.TimeControl{Byoyomi: 20 * time.Millisecond},
+	}.Run(context.Background())
+	if r.Forfeit == nil || !errors.Is(r.Forfeit, ErrTimeout) {
+		t.Fatalf("forfeit = %v, want black out of time", r.Forfeit)
+	}
+}
+
+func TestRunTimedBot(t *testing.T) {
+	// A bot that searched to its depth every move would lose on time.
+	tc := board.TimeControl{Base: 500 * time.Millisecond}
+	r := Game{
+		Black:     board.NewPengwin(60, "black"),
+		White:     board.RandomPlayer{},
+		BlackTime: tc,
+	}.Run(context.Background())
+
+	if r.Forfeit != nil {
+		t.Fatalf("forfeit: %v after %v", r.Forfeit, r.BlackUsed)
+	}
+	if r.BlackUsed > tc.Base {
+		t.Errorf("black used %v of %v", r.BlackUsed, tc.Base)
+	}
+}
+
+func TestReplaySameSeed(t *testing.T) {
+	random, _ := ParseEntrant("random")
+	level, _ := ParseEntrant("level:1")
+	opening := board.RandomOpening(board.NewRand(1), 4)
+
+	r := PlayRecord(random, level, opening, board.TimeControl{}, 42)
+	if r.Seed != 42 {
+		t.Errorf("seed = %d, want 42", r.Seed)
+	}
+	again, err := Replay(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != r {
+		t.Errorf("replay:\n%+v\nwant\n%+v", again, r)
+	}
+
+	// Another seed plays another game, for all but the unluckiest seeds.
+	if other := PlayRecord(random, level, opening, board.TimeControl{}, 43); other.Moves == r.Moves {
+		t.Errorf("seeds 42 and 43 played the same game %s", r.Moves)
+	}
+}
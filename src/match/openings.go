@@ -0,0 +1,36 @@
+package match
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+
+	"Othello-Engine/board"
+)
+
+// Openings reads the XOT list at path or, if path is empty, generates n
+// balanced openings of the given length, drawn from r and verified by a
+// depth-ply search. It fails rather than return no openings.
+func Openings(path string, r *rand.Rand, n, plies, depth, margin int) ([]board.Opening, error) {
+	if path == "" {
+		if n < 1 {
+			return nil, fmt.Errorf("need at least one opening, not %d", n)
+		}
+		return board.BalancedOpenings(r, n, plies, depth, margin)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	openings, err := board.LoadXOT(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if n > 0 && n < len(openings) {
+		openings = openings[:n]
+	}
+	return openings, nil
+}
@@ -0,0 +1,191 @@
+package match
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+
+	"Othello-Engine/board"
+)
+
+// SPRT is a sequential probability ratio test of H0: elo = Elo0 against
+// H1: elo = Elo1, with false positive rate Alpha and false negative rate
+// Beta. The log-likelihood ratio uses the normal approximation over
+// win/draw/loss results.
+type SPRT struct {
+	Elo0, Elo1  float64
+	Alpha, Beta float64
+
+	Wins, Draws, Losses int
+}
+
+// Add records the score (1, 0.5 or 0) of one game of the tested player.
+func (s *SPRT) Add(score float64) {
+	switch score {
+	case 1:
+		s.Wins++
+	case 0:
+		s.Losses++
+	default:
+		s.Draws++
+	}
+}
+
+func (s *SPRT) Games() int {
+	return s.Wins + s.Draws + s.Losses
+}
+
+// LLR is the log-likelihood ratio of H1 over H0 for the games so far.
+func (s *SPRT) LLR() float64 {
+	if s.Games() == 0 {
+		return 0
+	}
+
+	// An outcome that has not happened yet counts as half a game, so a
+	// one-sided start does not look like zero variance.
+	counts := [3]float64{float64(s.Wins), float64(s.Draws), float64(s.Losses)}
+	n := 0.0
+	for i := range counts {
+		if counts[i] == 0 {
+			counts[i] = 0.5
+		}
+		n += counts[i]
+	}
+
+	w, d, l := counts[0]/n, counts[1]/n, counts[2]/n
+	mean := w + d/2
+	variance := w*(1-mean)*(1-mean) + d*(0.5-mean)*(0.5-mean) + l*mean*mean
+
+	s0 := expectedScore(s.Elo0)
+	s1 := expectedScore(s.Elo1)
+	return n * (s1 - s0) * (2*mean - s0 - s1) / (2 * variance)
+}
+
+// Bounds returns the LLR below which H0 is accepted and above which H1 is.
+func (s *SPRT) Bounds() (lower, upper float64) {
+	return math.Log(s.Beta / (1 - s.Alpha)), math.Log((1 - s.Beta) / s.Alpha)
+}
+
+// Status is -1 once H0 is accepted, 1 once H1 is, and 0 while undecided.
+func (s *SPRT) Status() int {
+	lower, upper := s.Bounds()
+	switch llr := s.LLR(); {
+	case llr <= lower:
+		return -1
+	case llr >= upper:
+		return 1
+	}
+	return 0
+}
+
+func (s *SPRT) String() string {
+	lower, upper := s.Bounds()
+	elo, margin := EloInterval(s.scores())
+	return fmt.Sprintf("games %d  W-D-L %d-%d-%d  elo %+.1f ± %.1f  LLR %.2f [%.2f, %.2f]",
+		s.Games(), s.Wins, s.Draws, s.Losses, elo, margin, s.LLR(), lower, upper)
+}
+
+func (s *SPRT) scores() []float64 {
+	scores := make([]float64, 0, s.Games())
+	for range s.Wins {
+		scores = append(scores, 1)
+	}
+	for range s.Draws {
+		scores = append(scores, 0.5)
+	}
+	for range s.Losses {
+		scores = append(scores, 0)
+	}
+	return scores
+}
+
+func expectedScore(elo float64) float64 {
+	return 1 / (1 + math.Pow(10, -elo/400))
+}
+
+// Match plays test against base until the SPRT reaches a decision or
+// MaxGames is played. Openings are cycled, each played twice with colours
+// swapped.
+type Match struct {
+	Test, Base Entrant
+	Openings   []board.Opening
+	Workers    int
+	MaxGames   int               // 0 means no limit
+	Time       board.TimeControl // for every game; the zero value is no limit
+	Seed       int64             // game n, counting from 0, is played with seed Seed+n
+
+	Record   io.Writer // JSON lines, one per game; may be nil
+	Progress io.Writer // LLR after every game; may be nil
+}
+
+// Run plays the match and returns the test's final state.
+func (m *Match) Run(test SPRT) (SPRT, error) {
+	if len(m.Openings) == 0 {
+		return test, errors.New("no openings to play")
+	}
+
+	jobs := make(chan pairing)
+	type result struct {
+		testBlack bool
+		r         Record
+	}
+	results := make(chan result)
+	stop := make(chan struct{})
+
+	entrants := []Entrant{m.Test, m.Base}
+	var wg sync.WaitGroup
+	for range max(m.Workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range jobs {
+				results <- result{p.black == 0, PlayRecord(entrants[p.black], entrants[p.white], p.opening, m.Time, p.seed)}
+			}
+		}()
+	}
+	go func() {
+		defer func() {
+			close(jobs)
+			wg.Wait()
+			close(results)
+		}()
+		for n := 0; m.MaxGames == 0 || n < m.MaxGames; n++ {
+			p := pairing{0, 1, m.Openings[(n/2)%len(m.Openings)], m.Seed + int64(n)}
+			if n%2 == 1 {
+				p.black, p.white = 1, 0
+			}
+			select {
+			case jobs <- p:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	for res := range results {
+		if test.Status() != 0 {
+			continue // decided; drain games still in flight
+		}
+
+		score := res.r.BlackScore()
+		if !res.testBlack {
+			score = 1 - score
+		}
+		test.Add(score)
+
+		if m.Record != nil {
+			line, _ := json.Marshal(res.r)
+			m.Record.Write(append(line, '\n'))
+		}
+		if m.Progress != nil {
+			fmt.Fprintln(m.Progress, test.String())
+		}
+		if test.Status() != 0 {
+			close(stop)
+		}
+	}
+	return test, nil
+}
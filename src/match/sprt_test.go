@@ -0,0 +1,94 @@
+package match
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"Othello-Engine/board"
+)
+
+func TestLLR(t *testing.T) {
+	for _, tc := range []struct {
+		w, d, l    int
+		elo0, elo1 float64
+		llr        float64
+	}{
+		{0, 0, 0, 0, 10, 0},
+		{60, 20, 40, 0, 10, 0.6527},
+		{40, 20, 60, 0, 10, -0.7761},
+		{50, 0, 50, 0, 10, -0.0418},
+		{120, 60, 100, -5, 5, 0.7374},
+		// Outcomes not seen yet count as half a game each.
+		{10, 0, 0, 0, 50, 5.9683},
+	} {
+		s := SPRT{Elo0: tc.elo0, Elo1: tc.elo1, Wins: tc.w, Draws: tc.d, Losses: tc.l}
+		if got := s.LLR(); math.Abs(got-tc.llr) > 1e-3 {
+			t.Errorf("LLR of %d-%d-%d for [%g, %g] = %.4f, want %.4f", tc.w, tc.d, tc.l, tc.elo0, tc.elo1, got, tc.llr)
+		}
+	}
+}
+
+func TestSPRTBounds(t *testing.T) {
+	s := SPRT{Alpha: 0.05, Beta: 0.05}
+	lower, upper := s.Bounds()
+	if want := math.Log(19); math.Abs(upper-want) > 1e-9 || math.Abs(lower+want) > 1e-9 {
+		t.Errorf("bounds = [%g, %g], want ±%g", lower, upper, want)
+	}
+
+	for _, tc := range []struct {
+		w, l, status int
+	}{
+		{100, 0, 1},
+		{0, 100, -1},
+		{5, 5, 0},
+	} {
+		s := SPRT{Elo1: 50, Alpha: 0.05, Beta: 0.05, Wins: tc.w, Losses: tc.l}
+		if got := s.Status(); got != tc.status {
+			t.Errorf("status after %d-0-%d = %d, want %d", tc.w, tc.l, got, tc.status)
+		}
+	}
+}
+
+// resigner never moves, so it forfeits every game.
+type resigner struct{}
+
+func (resigner) GetMove(*board.Board) (int, int, bool) { return 0, 0, false }
+
+func stub(name string) Entrant {
+	return Entrant{Name: name, New: func(string, *rand.Rand) board.Player { return resigner{} }}
+}
+
+func TestMatchStopsOnDecision(t *testing.T) {
+	random, _ := ParseEntrant("random")
+	openings := []board.Opening{{Board: board.NewBoard()}}
+	m := Match{Test: random, Base: stub("resigner"), Openings: openings, MaxGames: 1000}
+
+	s, err := m.Run(SPRT{Elo1: 50, Alpha: 0.05, Beta: 0.05})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Status() != 1 || s.Losses != 0 || s.Games() >= 1000 {
+		t.Errorf("after beating a resigner: %s", s.String())
+	}
+}
+
+func TestMatchPlaysMaxGames(t *testing.T) {
+	// Black forfeits every game, so the players trade wins and the test
+	// never decides.
+	openings := []board.Opening{{Board: board.NewBoard()}}
+	m := Match{Test: stub("a"), Base: stub("b"), Openings: openings, MaxGames: 3}
+
+	s, err := m.Run(SPRT{Elo1: 50, Alpha: 0.05, Beta: 0.05})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Games() != 3 || s.Wins != 1 || s.Losses != 2 {
+		t.Errorf("after 3 games: %s", s.String())
+	}
+
+	m.Openings = nil
+	if _, err := m.Run(SPRT{Elo1: 50}); err == nil {
+		t.Errorf("a match without openings ran")
+	}
+}
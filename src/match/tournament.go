@@ -0,0 +1,334 @@
+package match
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"Othello-Engine/board"
+)
+
+// Entrant is a named player. New builds a fresh player for one game, since
+// bots are bound to a side and external engines hold a process; its random
+// choices, if any, are drawn from r.
+type Entrant struct {
+	Name string
+	New  func(side string, r *rand.Rand) board.Player
+}
+
+// ParseEntrant builds an entrant from a spec: "pengwin:DEPTH",
+// "greedy:DEPTH", "level:LEVEL" (see board.Levels), "random" or
+// "nboard:DEPTH:PATH".
+func ParseEntrant(spec string) (Entrant, error) {
+	kind, arg, _ := strings.Cut(spec, ":")
+
+	depth := func(s string) (int, error) {
+		d, err := strconv.Atoi(s)
+		if err != nil || d < 1 {
+			return 0, fmt.Errorf("bad depth in %q", spec)
+		}
+		return d, nil
+	}
+
+	switch kind {
+	case "pengwin":
+		d, err := depth(arg)
+		if err != nil {
+			return Entrant{}, err
+		}
+		return Entrant{Name: spec, New: func(side string, r *rand.Rand) board.Player {
+			p := board.NewPengwin(d, side)
+			p.Rand = r
+			return p
+		}}, nil
+	case "greedy":
+		d, err := depth(arg)
+		if err != nil {
+			return Entrant{}, err
+		}
+		return Entrant{Name: spec, New: func(side string, r *rand.Rand) board.Player {
+			g := board.NewGreedy(d, side)
+			g.Rand = r
+			return g
+		}}, nil
+	case "level":
+		level, err := strconv.Atoi(arg)
+		if err == nil {
+			_, err = board.NewLevel(level, "black")
+		}
+		if err != nil {
+			return Entrant{}, fmt.Errorf("bad level in %q: want 1 to %d", spec, board.MaxLevel)
+		}
+		return Entrant{Name: spec, New: func(side string, r *rand.Rand) board.Player {
+			p, _ := board.NewLevel(level, side)
+			p.Rand = r
+			return p
+		}}, nil
+	case "random":
+		return Entrant{Name: spec, New: func(_ string, r *rand.Rand) board.Player { return board.RandomPlayer{Rand: r} }}, nil
+	case "nboard":
+		ds, path, ok := strings.Cut(arg, ":")
+		if !ok {
+			return Entrant{}, fmt.Errorf("want nboard:DEPTH:PATH, got %q", spec)
+		}
+		d, err := depth(ds)
+		if err != nil {
+			return Entrant{}, err
+		}
+		return Entrant{Name: spec, New: func(string, *rand.Rand) board.Player { return board.NewExternal(path, d) }}, nil
+	}
+	return Entrant{}, fmt.Errorf("unknown player %q", spec)
+}
+
+// Record is one finished game, written as a line of JSON to the record file.
+type Record struct {
+	Black      string `json:"black"`
+	White      string `json:"white"`
+	Opening    string `json:"opening"`
+	Moves      string `json:"moves"`
+	BlackDiscs int    `json:"black_discs"`
+	WhiteDiscs int    `json:"white_discs"`
+	Forfeit    string `json:"forfeit,omitempty"`
+	Time       string `json:"time,omitempty"` // the time control, as ParseTimeControl reads it
+	Seed       int64  `json:"seed"`           // of the players' random choices; see Replay
+}
+
+// BlackScore is 1 for a black win, 0.5 for a draw and 0 for a loss.
+func (r Record) BlackScore() float64 {
+	switch {
+	case r.Forfeit != "":
+		if r.Forfeit == "black" {
+			return 0
+		}
+		return 1
+	case r.BlackDiscs > r.WhiteDiscs:
+		return 1
+	case r.BlackDiscs < r.WhiteDiscs:
+		return 0
+	}
+	return 0.5
+}
+
+// PlayRecord plays one game between two entrants from an opening, both
+// under the time control tc and drawing their random choices from seed.
+func PlayRecord(black, white Entrant, opening board.Opening, tc board.TimeControl, seed int64) Record {
+	rng := board.NewRand(seed)
+	bp, wp := black.New("black", rng), white.New("white", rng)
+	defer closePlayer(bp)
+	defer closePlayer(wp)
+
+	start := opening.Board
+	res := Game{Black: bp, White: wp, Start: &start, BlackTime: tc, WhiteTime: tc}.Run(context.Background())
+	r := Record{
+		Black:      black.Name,
+		White:      white.Name,
+		Opening:    opening.Moves,
+		Moves:      strings.Join(res.Moves, ""),
+		BlackDiscs: res.BlackDiscs,
+		WhiteDiscs: res.WhiteDiscs,
+		Time:       tc.String(),
+		Seed:       seed,
+	}
+	if res.Forfeit != nil {
+		r.Forfeit = sideName(res.Forfeit.Black)
+	}
+	return r
+}
+
+// Replay plays the game of a record again: the same entrants from the
+// same opening, under the same time control and with the same seed. Bots
+// searching to a fixed depth make the same moves again; bots short of time
+// may not.
+func Replay(rec Record) (Record, error) {
+	black, err := ParseEntrant(rec.Black)
+	if err != nil {
+		return Record{}, err
+	}
+	white, err := ParseEntrant(rec.White)
+	if err != nil {
+		return Record{}, err
+	}
+	opening, err := board.ParseOpening(rec.Opening)
+	if err != nil {
+		return Record{}, err
+	}
+	tc, err := board.ParseTimeControl(rec.Time)
+	if err != nil {
+		return Record{}, err
+	}
+	return PlayRecord(black, white, opening, tc, rec.Seed), nil
+}
+
+// Tournament plays every opening twice, colours swapped, for each pairing:
+// all pairs of entrants, or in a gauntlet the first entrant against the rest.
+type Tournament struct {
+	Entrants []Entrant
+	Gauntlet bool
+	Openings []board.Opening
+	Workers  int
+	Time     board.TimeControl // for every game; the zero value is no limit
+	Seed     int64             // game n, counting from 0, is played with seed Seed+n
+
+	Record   io.Writer // JSON lines, one per game; may be nil
+	Progress io.Writer // one line per finished game; may be nil
+}
+
+type pairing struct {
+	black, white int
+	opening      board.Opening
+	seed         int64
+}
+
+func (t *Tournament) pairings() []pairing {
+	var ps []pairing
+	for i := range t.Entrants {
+		for j := i + 1; j < len(t.Entrants); j++ {
+			if t.Gauntlet && i != 0 {
+				break
+			}
+			for _, o := range t.Openings {
+				seed := t.Seed + int64(len(ps))
+				ps = append(ps, pairing{i, j, o, seed}, pairing{j, i, o, seed + 1})
+			}
+		}
+	}
+	return ps
+}
+
+// Run plays the tournament on Workers goroutines and returns the results.
+func (t *Tournament) Run() *Table {
+	ps := t.pairings()
+	jobs := make(chan pairing)
+	type result struct {
+		p pairing
+		r Record
+	}
+	results := make(chan result)
+
+	workers := max(t.Workers, 1)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range jobs {
+				results <- result{p, PlayRecord(t.Entrants[p.black], t.Entrants[p.white], p.opening, t.Time, p.seed)}
+			}
+		}()
+	}
+	go func() {
+		for _, p := range ps {
+			jobs <- p
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	table := NewTable(t.Entrants)
+	done := 0
+	for res := range results {
+		done++
+		table.Add(res.p.black, res.p.white, res.r)
+
+		if t.Record != nil {
+			line, _ := json.Marshal(res.r)
+			t.Record.Write(append(line, '\n'))
+		}
+		if t.Progress != nil {
+			fmt.Fprintf(t.Progress, "[%d/%d] %s - %s %d-%d\n", done, len(ps),
+				res.r.Black, res.r.White, res.r.BlackDiscs, res.r.WhiteDiscs)
+		}
+	}
+	return table
+}
+
+// Table accumulates tournament results per entrant.
+type Table struct {
+	Names  []string
+	points [][]float64 // points[i][j]: what i scored against j
+	games  [][]int
+	discs  []int
+	scores [][]float64 // per-game scores of each entrant, for Elo
+}
+
+func NewTable(entrants []Entrant) *Table {
+	n := len(entrants)
+	t := &Table{
+		Names:  make([]string, n),
+		points: make([][]float64, n),
+		games:  make([][]int, n),
+		discs:  make([]int, n),
+		scores: make([][]float64, n),
+	}
+	for i, e := range entrants {
+		t.Names[i] = e.Name
+		t.points[i] = make([]float64, n)
+		t.games[i] = make([]int, n)
+	}
+	return t
+}
+
+// Add records a game between entrants black and white.
+func (t *Table) Add(black, white int, r Record) {
+	s := r.BlackScore()
+	t.points[black][white] += s
+	t.points[white][black] += 1 - s
+	t.games[black][white]++
+	t.games[white][black]++
+	t.discs[black] += r.BlackDiscs
+	t.discs[white] += r.WhiteDiscs
+	t.scores[black] = append(t.scores[black], s)
+	t.scores[white] = append(t.scores[white], 1-s)
+}
+
+// Print writes the cross-table, best entrant first. Elo is measured
+// against the average opponent each entrant met.
+func (t *Table) Print(w io.Writer) {
+	order := make([]int, len(t.Names))
+	total := make([]float64, len(t.Names))
+	width := 4
+	for i, name := range t.Names {
+		order[i] = i
+		for _, p := range t.points[i] {
+			total[i] += p
+		}
+		width = max(width, len(name))
+	}
+	sort.SliceStable(order, func(a, b int) bool { return total[order[a]] > total[order[b]] })
+
+	fmt.Fprintf(w, "%3s %-*s", "", width, "")
+	for k := range order {
+		fmt.Fprintf(w, " %9d", k+1)
+	}
+	fmt.Fprintf(w, " %8s %6s %6s %14s\n", "Score", "Games", "Discs", "Elo")
+
+	for k, i := range order {
+		fmt.Fprintf(w, "%2d. %-*s", k+1, width, t.Names[i])
+		for _, j := range order {
+			if i == j || t.games[i][j] == 0 {
+				fmt.Fprintf(w, " %9s", "-")
+				continue
+			}
+			fmt.Fprintf(w, " %9s", fmt.Sprintf("%g/%d", t.points[i][j], t.games[i][j]))
+		}
+
+		n := len(t.scores[i])
+		avg := 0.0
+		if n > 0 {
+			avg = float64(t.discs[i]) / float64(n)
+		}
+		if n == 0 {
+			fmt.Fprintf(w, " %8g %6d %6s %14s\n", total[i], n, "-", "-")
+			continue
+		}
+		elo, margin := EloInterval(t.scores[i])
+		fmt.Fprintf(w, " %8g %6d %6.1f %+6.0f ± %-5.0f\n", total[i], n, avg, elo, margin)
+	}
+}
@@ -0,0 +1,82 @@
+package match
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"Othello-Engine/board"
+)
+
+func TestEloInterval(t *testing.T) {
+	for _, tc := range []struct {
+		scores      []float64
+		elo, margin float64
+	}{
+		{[]float64{1, 0}, 0, 2400},
+		{[]float64{0.5, 0.5, 0.5, 0.5}, 0, 0},
+		{[]float64{1, 1, 1, 0}, 190.8, 1263.2},
+		{[]float64{1, 0.5, 0.5, 0}, 0, 296.6},
+		{[]float64{1, 1, 0.5, 0.5}, 190.8, 458.0},
+		// Sweeps are finite, as if half a game had gone the other way.
+		{[]float64{1, 1, 1, 1, 1, 1, 1, 1, 1, 1}, 520.4, 1066.2},
+		{[]float64{0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, -520.4, 1066.2},
+		{[]float64{1}, 120.4, 2400},
+		{[]float64{0}, -120.4, 2400},
+	} {
+		elo, margin := EloInterval(tc.scores)
+		if math.Abs(elo-tc.elo) > 0.1 || math.Abs(margin-tc.margin) > 0.1 {
+			t.Errorf("EloInterval(%v) = %.1f ± %.1f, want %.1f ± %.1f", tc.scores, elo, margin, tc.elo, tc.margin)
+		}
+	}
+
+	if _, margin := EloInterval(nil); !math.IsInf(margin, 1) {
+		t.Errorf("margin without games = %v, want +Inf", margin)
+	}
+}
+
+func TestTablePrintsSweeps(t *testing.T) {
+	table := NewTable([]Entrant{{Name: "a"}, {Name: "b"}, {Name: "idle"}})
+	table.Add(0, 1, Record{BlackDiscs: 64})
+	table.Add(1, 0, Record{WhiteDiscs: 64})
+
+	var out strings.Builder
+	table.Print(&out)
+	if strings.Contains(out.String(), "Inf") || strings.Contains(out.String(), "NaN") {
+		t.Errorf("table:\n%s", out.String())
+	}
+}
+
+func TestPairings(t *testing.T) {
+	entrants := []Entrant{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	openings, err := board.BalancedOpenings(board.NewRand(1), 2, 4, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		gauntlet bool
+		pairs    [][2]int // each played from both openings, then colours swapped
+	}{
+		{false, [][2]int{{0, 1}, {0, 2}, {1, 2}}},
+		{true, [][2]int{{0, 1}, {0, 2}}},
+	} {
+		tour := Tournament{Entrants: entrants, Gauntlet: tc.gauntlet, Openings: openings, Seed: 100}
+		ps := tour.pairings()
+		if len(ps) != 4*len(tc.pairs) {
+			t.Fatalf("gauntlet %v: %d games, want %d", tc.gauntlet, len(ps), 4*len(tc.pairs))
+		}
+
+		for k, p := range ps {
+			pair, o := tc.pairs[k/4], openings[k/2%2]
+			black, white := pair[0], pair[1]
+			if k%2 == 1 {
+				black, white = white, black
+			}
+			if p.black != black || p.white != white || p.opening != o || p.seed != 100+int64(k) {
+				t.Errorf("gauntlet %v: game %d = %d-%d seed %d, want %d-%d seed %d from %s",
+					tc.gauntlet, k, p.black, p.white, p.seed, black, white, 100+k, o.Moves)
+			}
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// Stand-in NBoard engine built on Pengwin. It is used to exercise
+// board.External; the -fault flag makes it misbehave on purpose.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"Othello-Engine/board"
+)
+
+func main() {
+	fault := flag.String("fault", "", `misbehave on "go": "crash", "illegal", "garbage" or "slow"`)
+	delay := flag.Duration("delay", 10*time.Second, `how long to think with -fault=slow`)
+	flag.Parse()
+
+	depth := 4
+	game := board.NewBoard()
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		cmd, arg, _ := strings.Cut(line, " ")
+
+		switch cmd {
+		case "nboard":
+		case "quit":
+			return
+		case "ping":
+			fmt.Fprintf(out, "pong %s\n", arg)
+		case "set":
+			key, value, _ := strings.Cut(arg, " ")
+			switch key {
+			case "depth":
+				if d, err := strconv.Atoi(value); err == nil && d > 0 {
+					depth = d
+				}
+			case "game":
+				b, err := board.ParseGGF(value)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "nboard: %v\n", err)
+					continue
+				}
+				game = b
+			}
+		case "go":
+			switch *fault {
+			case "crash":
+				os.Exit(2)
+			case "illegal":
+				fmt.Fprintln(out, "=== A1")
+				out.Flush()
+				continue
+			case "garbage":
+				fmt.Fprintln(out, "=== Z9")
+				out.Flush()
+				continue
+			case "slow":
+				time.Sleep(*delay)
+			}
+
+			fmt.Fprintln(out, "=== "+bestMove(game, depth))
+		}
+		out.Flush()
+	}
+}
+
+func bestMove(b board.Board, depth int) string {
+	side := "white"
+	if b.BlackTurn {
+		side = "black"
+	}
+
+	x, y, ok := board.NewPengwin(depth, side).GetMove(&b)
+	if !ok {
+		return "PA"
+	}
+	return strings.ToUpper(board.SquareName(x, y))
+}
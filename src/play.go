@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+
+	"Othello-Engine/board"
+	"Othello-Engine/match"
+	"Othello-Engine/repl"
+)
+
+// parsePlayer builds the bot for one side from an entrant spec as
+// match.ParseEntrant reads it, such as "pengwin:8", drawing its random
+// choices from r. Humans play through the REPL instead.
+func parsePlayer(spec, side string, r *rand.Rand) (board.Player, error) {
+	e, err := match.ParseEntrant(spec)
+	if err != nil {
+		return nil, usageError{err}
+	}
+	return e.New(side, r), nil
+}
+
+// parseBoard reads a position: as board.Position writes it, a GGF record,
+// or a transcript of moves from the start.
+func parseBoard(s string) (board.Board, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "(;") {
+		return board.ParseGGF(s)
+	}
+	if b, err := board.ParsePosition(s); err == nil {
+		return b, nil
+	}
+	g, err := board.ParseTranscript(s)
+	if err != nil {
+		return board.Board{}, usageError{fmt.Errorf("position %q: %w", s, err)}
+	}
+	return g.Board(), nil
+}
+
+func closePlayer(p board.Player) {
+	if c, ok := p.(io.Closer); ok {
+		c.Close()
+	}
+}
+
+func play(args []string) error {
+	flags := newFlagSet("play", "")
+	blackSpec := flags.String("black", "human", "black player: human, pengwin:D, greedy:D, level:N, random or nboard:D:PATH")
+	whiteSpec := flags.String("white", fmt.Sprintf("pengwin:%d", depth), "white player, as for -black")
+	position := flags.String("position", "", "start position or moves from the start; empty for the normal start")
+	timeControl := flags.String("time", "", "each side's clock, BASE[+INC][/BYOYOMI] such as 5m+2s or 10m/30s; empty for none")
+	hintDepth := flags.Int("depth", depth, "search depth of the hint and eval commands")
+	color := flags.Bool("color", repl.IsTerminal(os.Stdout) && os.Getenv("NO_COLOR") == "", "draw the board in colour")
+	seed := flags.Int64("seed", 0, "seed of the bots' random choices; 0 picks one")
+	flags.Parse(args)
+
+	start, err := parseBoard(*position)
+	if err != nil {
+		return err
+	}
+	if err := start.Validate(); err != nil {
+		return usageError{fmt.Errorf("position: %w", err)}
+	}
+	if *hintDepth < 1 {
+		return usageError{fmt.Errorf("depth must be at least 1")}
+	}
+	tc, err := board.ParseTimeControl(*timeControl)
+	if err != nil {
+		return usageError{err}
+	}
+	if *seed == 0 {
+		*seed = board.NewSeed()
+	}
+	rng := board.NewRand(*seed)
+
+	if *blackSpec == "human" || *whiteSpec == "human" {
+		g := board.NewGame(start)
+		g.Seed = *seed
+		r := repl.New(g, os.Stdin, os.Stdout)
+		r.Depth, r.Color, r.Time, r.Rand = *hintDepth, *color, tc, rng
+		botSpec := *whiteSpec
+		if *blackSpec != "human" {
+			r.Human, botSpec = "white", *blackSpec
+		}
+		if botSpec != "human" {
+			e, err := match.ParseEntrant(botSpec)
+			if err != nil {
+				return usageError{err}
+			}
+			r.Bot = &e
+		}
+		return r.Run()
+	}
+
+	fmt.Printf("Seed %d\n", *seed)
+	black, err := parsePlayer(*blackSpec, "black", rng)
+	if err != nil {
+		return err
+	}
+	defer closePlayer(black)
+	white, err := parsePlayer(*whiteSpec, "white", rng)
+	if err != nil {
+		return err
+	}
+	defer closePlayer(white)
+
+	match.Game{Black: black, White: white, Start: &start, BlackTime: tc, WhiteTime: tc, Out: os.Stdout}.Run(context.Background())
+	return nil
+}
+
+// selfplay plays two bots from balanced openings, swapping colours each
+// game, and scores the first against the second.
+func selfplay(args []string) error {
+	flags := newFlagSet("selfplay", "")
+	firstSpec := flags.String("black", fmt.Sprintf("pengwin:%d", depth), "player with black in the first game: pengwin:D, greedy:D, level:N, random or nboard:D:PATH")
+	secondSpec := flags.String("white", fmt.Sprintf("greedy:%d", depth), "player with white in the first game")
+	games := flags.Int("games", 10, "number of games")
+	plies := flags.Int("plies", 8, "opening length in plies")
+	verify := flags.Int("verify", 6, "search depth used to check openings are balanced")
+	margin := flags.Int("margin", 2, "largest opening score accepted as balanced")
+	xot := flags.String("xot", "", "read openings from an XOT list instead of generating them")
+	record := flags.String("record", "", "file to append game records to")
+	timeControl := flags.String("time", "", "each side's clock, as for play; empty for none")
+	seed := flags.Int64("seed", 0, "seed of the openings and the bots' random choices; game n is played with seed+n; 0 picks one")
+	replay := flags.String("replay", "", "play the games recorded in this file again, instead, and check they end the same")
+	flags.Parse(args)
+
+	if *replay != "" {
+		return replayRecords(*replay)
+	}
+
+	tc, err := board.ParseTimeControl(*timeControl)
+	if err != nil {
+		return usageError{err}
+	}
+
+	first, err := match.ParseEntrant(*firstSpec)
+	if err != nil {
+		return usageError{err}
+	}
+	second, err := match.ParseEntrant(*secondSpec)
+	if err != nil {
+		return usageError{err}
+	}
+	if *games < 1 {
+		return usageError{fmt.Errorf("need at least one game")}
+	}
+
+	if *seed == 0 {
+		*seed = board.NewSeed()
+	}
+	fmt.Printf("Seed %d\n", *seed)
+
+	// Each opening is played twice, once with each colour.
+	starts, err := match.Openings(*xot, board.NewRand(*seed), (*games+1)/2, *plies, *verify, *margin)
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer
+	if *record != "" {
+		f, err := os.OpenFile(*record, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	scores := make([]float64, 0, *games)
+	for i := 0; i < *games; i++ {
+		opening := starts[i/2%len(starts)]
+		var r match.Record
+		var score float64
+		if i%2 == 0 {
+			r = match.PlayRecord(first, second, opening, tc, *seed+int64(i))
+			score = r.BlackScore()
+		} else {
+			r = match.PlayRecord(second, first, opening, tc, *seed+int64(i))
+			score = 1 - r.BlackScore()
+		}
+		scores = append(scores, score)
+
+		result := fmt.Sprintf("%d-%d", r.BlackDiscs, r.WhiteDiscs)
+		if r.Forfeit != "" {
+			result = r.Forfeit + " forfeits"
+		}
+		fmt.Printf("%3d  %s - %s  %s  %s%s\n", i+1, r.Black, r.White, result, r.Opening, r.Moves)
+
+		if out != nil {
+			line, _ := json.Marshal(r)
+			out.Write(append(line, '\n'))
+		}
+	}
+
+	total := 0.0
+	for _, s := range scores {
+		total += s
+	}
+	elo, margin95 := match.EloInterval(scores)
+	fmt.Printf("%s scored %.1f/%d against %s: Elo %+.0f ± %.0f\n", first.Name, total, len(scores), second.Name, elo, margin95)
+	return nil
+}
+
+// replayRecords plays each game recorded in path again, as a bug report's
+// record would be, and reports any that end differently.
+func replayRecords(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	differ := 0
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		var want match.Record
+		if err := json.Unmarshal(scanner.Bytes(), &want); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		got, err := match.Replay(want)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+
+		verdict := "same"
+		if got != want {
+			verdict = "differs: " + got.Opening + got.Moves
+			differ++
+		}
+		fmt.Printf("%3d  %s - %s  %s%s  %s\n", line, want.Black, want.White, want.Opening, want.Moves, verdict)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if differ > 0 {
+		return fmt.Errorf("%d games ended differently", differ)
+	}
+	return nil
+}
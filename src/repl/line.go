@@ -0,0 +1,166 @@
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// ErrInterrupted is returned by ReadLine when Ctrl-C is typed.
+var ErrInterrupted = errors.New("interrupted")
+
+// LineReader reads lines with some editing when typed at a terminal: the
+// left and right arrows move along the line, and up and down step through
+// History. Other input is read a line at a time.
+type LineReader struct {
+	History []string
+
+	in  *bufio.Reader
+	out io.Writer
+	fd  int // the terminal, or -1
+}
+
+func NewLineReader(in io.Reader, out io.Writer) *LineReader {
+	r := &LineReader{in: bufio.NewReader(in), out: out, fd: -1}
+	if f, ok := in.(*os.File); ok && isTerminal(int(f.Fd())) {
+		r.fd = int(f.Fd())
+	}
+	return r
+}
+
+// IsTerminal reports whether f is a terminal.
+func IsTerminal(f *os.File) bool {
+	return isTerminal(int(f.Fd()))
+}
+
+// ReadLine shows prompt and reads a line, without its newline, adding it
+// to History. It returns io.EOF at the end of the input.
+func (r *LineReader) ReadLine(prompt string) (string, error) {
+	line, err := r.edit(prompt)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(line) != "" && (len(r.History) == 0 || r.History[len(r.History)-1] != line) {
+		r.History = append(r.History, line)
+	}
+	return line, nil
+}
+
+// readLine reads a line as it comes, for input that is not a terminal.
+func (r *LineReader) readLine(prompt string) (string, error) {
+	fmt.Fprint(r.out, prompt)
+	line, err := r.in.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+// edit reads a line a key at a time from the terminal, redrawing it after
+// each key.
+func (r *LineReader) edit(prompt string) (string, error) {
+	if r.fd < 0 {
+		return r.readLine(prompt)
+	}
+	restore, err := makeRaw(r.fd)
+	if err != nil {
+		return r.readLine(prompt)
+	}
+	defer restore()
+
+	var line []rune
+	pos := 0                // cursor, as an index into line
+	entry := len(r.History) // the History entry shown; len(History) is the new line
+	var typed []rune        // the new line, kept while History is shown
+
+	redraw := func() {
+		fmt.Fprintf(r.out, "\r%s%s\x1b[K", prompt, string(line))
+		if back := len(line) - pos; back > 0 {
+			fmt.Fprintf(r.out, "\x1b[%dD", back)
+		}
+	}
+	recall := func(i int) {
+		if entry == len(r.History) {
+			typed = line
+		}
+		entry = i
+		if entry == len(r.History) {
+			line = typed
+		} else {
+			line = []rune(r.History[entry])
+		}
+		pos = len(line)
+	}
+
+	redraw()
+	for {
+		c, _, err := r.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		switch c {
+		case '\r', '\n':
+			fmt.Fprint(r.out, "\r\n")
+			return string(line), nil
+		case 3: // Ctrl-C
+			fmt.Fprint(r.out, "^C\r\n")
+			return "", ErrInterrupted
+		case 4: // Ctrl-D
+			if len(line) == 0 {
+				fmt.Fprint(r.out, "\r\n")
+				return "", io.EOF
+			}
+		case 1: // Ctrl-A
+			pos = 0
+		case 5: // Ctrl-E
+			pos = len(line)
+		case 21: // Ctrl-U
+			line, pos = slices.Clone(line[pos:]), 0
+		case 8, 127: // backspace
+			if pos > 0 {
+				line = slices.Delete(line, pos-1, pos)
+				pos--
+			}
+		case 27: // an escape sequence, such as an arrow key
+			if b, _ := r.in.ReadByte(); b != '[' && b != 'O' {
+				break
+			}
+			key, _ := r.in.ReadByte()
+			switch key {
+			case 'A':
+				if entry > 0 {
+					recall(entry - 1)
+				}
+			case 'B':
+				if entry < len(r.History) {
+					recall(entry + 1)
+				}
+			case 'C':
+				pos = min(pos+1, len(line))
+			case 'D':
+				pos = max(pos-1, 0)
+			case 'H':
+				pos = 0
+			case 'F':
+				pos = len(line)
+			case '3': // delete, ESC [ 3 ~
+				r.in.ReadByte()
+				if pos < len(line) {
+					line = slices.Delete(line, pos, pos+1)
+				}
+			}
+		default:
+			if unicode.IsPrint(c) {
+				line = slices.Insert(slices.Clone(line), pos, c)
+				pos++
+			}
+		}
+		redraw()
+	}
+}
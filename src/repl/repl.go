@@ -0,0 +1,473 @@
+// Package repl plays Othello in the terminal. Moves are typed as squares
+// such as "f5", between commands that take them back, ask the engine for
+// a hint, save the game and so on.
+package repl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"Othello-Engine/board"
+	"Othello-Engine/match"
+)
+
+const help = `Commands:
+  f4          play a move
+  undo        take back your last move
+  hint        suggest a move
+  moves       list the legal moves
+  eval        score every legal move
+  save FILE   save the game, as GGF if FILE ends in .ggf, else as moves
+  load FILE   load a saved game
+  flip        swap sides with the bot
+  help        show this list
+  quit        leave`
+
+// REPL is a game in the terminal between a human and a bot, or two humans
+// taking turns at the keyboard.
+type REPL struct {
+	Game  *board.Game
+	Human string         // the human's side against Bot, "black" or "white"
+	Bot   *match.Entrant // the opponent, or nil when humans play both sides
+	Depth int            // search depth of hint and eval
+	Color bool           // draw the board with ANSI colours
+
+	// Time is each side's clock; the zero value is no limit. A human's
+	// clock is checked when they enter something, so it may have run out
+	// a while before they hear of it.
+	Time board.TimeControl
+
+	// Rand makes the bot's random choices; nil for the global source.
+	// Game.Seed, if it made Rand, is shown at the start and saved with
+	// the game.
+	Rand *rand.Rand
+
+	lines     *LineReader
+	out       io.Writer
+	bot       board.Player   // Bot, built for its side
+	clocks    [2]board.Clock // black's and white's, stopped at turnStart
+	turnStart time.Time      // when the side to move was first to move
+	flagged   string         // the side that ran out of time, if any
+}
+
+func New(g *board.Game, in io.Reader, out io.Writer) *REPL {
+	return &REPL{Game: g, Human: "black", Depth: 8, lines: NewLineReader(in, out), out: out}
+}
+
+// Run plays until the human quits or the input ends. It returns an error
+// only if the bot fails to move; running out of time just loses the game.
+func (r *REPL) Run() error {
+	r.newBot()
+	defer r.closeBot()
+	r.startClocks()
+
+	if r.Game.Seed != 0 {
+		fmt.Fprintf(r.out, "Seed %d\n", r.Game.Seed)
+	}
+	fmt.Fprintln(r.out, `Type a move such as "f4", or "help" for the commands.`)
+	r.show()
+	for {
+		if !r.over() && r.botToMove() {
+			if err := r.botMove(); err != nil {
+				return err
+			}
+			r.show()
+			continue
+		}
+
+		line, err := r.lines.ReadLine(r.prompt())
+		if errors.Is(err, io.EOF) || errors.Is(err, ErrInterrupted) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if r.outOfTime() {
+			r.show()
+		}
+		quit, err := r.exec(strings.Fields(line))
+		if err != nil {
+			fmt.Fprintln(r.out, err)
+		}
+		if quit {
+			return nil
+		}
+	}
+}
+
+// exec runs one command; quit says whether to stop.
+func (r *REPL) exec(args []string) (quit bool, err error) {
+	if len(args) == 0 {
+		return false, nil
+	}
+
+	switch cmd := strings.ToLower(args[0]); cmd {
+	case "quit", "exit", "q":
+		return true, nil
+	case "help", "?":
+		fmt.Fprintln(r.out, help)
+	case "moves":
+		r.moves()
+	case "undo":
+		return false, r.undo()
+	case "hint":
+		return false, r.hint()
+	case "eval":
+		return false, r.eval()
+	case "save":
+		if len(args) != 2 {
+			return false, errors.New("usage: save FILE")
+		}
+		return false, r.save(args[1])
+	case "load":
+		if len(args) != 2 {
+			return false, errors.New("usage: load FILE")
+		}
+		return false, r.load(args[1])
+	case "flip":
+		return false, r.flip()
+	default:
+		if len(args) == 1 && len(cmd) == 2 {
+			return false, r.play(cmd)
+		}
+		return false, fmt.Errorf("unknown command %q; type help for the list", args[0])
+	}
+	return false, nil
+}
+
+func sideName(black bool) string {
+	if black {
+		return "black"
+	}
+	return "white"
+}
+
+func title(side string) string {
+	return strings.ToUpper(side[:1]) + side[1:]
+}
+
+func (r *REPL) newBot() {
+	if r.Bot != nil {
+		r.bot = r.Bot.New(otherSide(r.Human), r.Rand)
+	}
+}
+
+func (r *REPL) closeBot() {
+	if c, ok := r.bot.(io.Closer); ok {
+		c.Close()
+	}
+	r.bot = nil
+}
+
+func otherSide(side string) string {
+	if side == "black" {
+		return "white"
+	}
+	return "black"
+}
+
+// over reports whether the game has ended, on the board or on time.
+func (r *REPL) over() bool {
+	b := r.Game.Board()
+	return r.flagged != "" || b.GameOver()
+}
+
+func turn(b board.Board) int {
+	if b.BlackTurn {
+		return 0
+	}
+	return 1
+}
+
+// startClocks sets both clocks to the full time and starts the one of
+// the side to move.
+func (r *REPL) startClocks() {
+	r.clocks = [2]board.Clock{board.NewClock(r.Time), board.NewClock(r.Time)}
+	r.flagged = ""
+	r.turnStart = time.Now()
+}
+
+// stopClock charges the side that just moved for its move and starts
+// the other clock. It reports false if the mover ran out of time.
+func (r *REPL) stopClock(mover board.Board) bool {
+	ok := r.clocks[turn(mover)].Spend(time.Since(r.turnStart))
+	r.turnStart = time.Now()
+	if !ok {
+		r.flag(mover)
+	}
+	return ok
+}
+
+// outOfTime flags the side to move if its time is up, and reports whether
+// a side has lost on time.
+func (r *REPL) outOfTime() bool {
+	b := r.Game.Board()
+	if r.flagged == "" && r.Time.Limited() && !b.GameOver() && time.Since(r.turnStart) > r.clocks[turn(b)].Available() {
+		r.clocks[turn(b)].Left = 0
+		r.flag(b)
+	}
+	return r.flagged != ""
+}
+
+func (r *REPL) flag(b board.Board) {
+	r.flagged = sideName(b.BlackTurn)
+	fmt.Fprintf(r.out, "%s ran out of time\n", title(r.flagged))
+}
+
+// clockText shows both clocks, the running one as it stands now.
+func (r *REPL) clockText() string {
+	b := r.Game.Board()
+	var text [2]string
+	for i, c := range r.clocks {
+		if i == turn(b) && !r.over() {
+			c = c.Running(time.Since(r.turnStart))
+		}
+		text[i] = formatClock(c)
+	}
+	return fmt.Sprintf("Clocks: black %s, white %s.", text[0], text[1])
+}
+
+func formatClock(c board.Clock) string {
+	if c.Left <= 0 && c.Byoyomi > 0 {
+		return fmt.Sprintf("byoyomi %s", c.Byoyomi.Round(time.Second))
+	}
+	left := max(c.Left, 0)
+	if left < 10*time.Second {
+		return fmt.Sprintf("0:%04.1f", left.Seconds())
+	}
+	left = left.Round(time.Second)
+	return fmt.Sprintf("%d:%02d", int(left.Minutes()), int(left.Seconds())%60)
+}
+
+func (r *REPL) botToMove() bool {
+	return r.bot != nil && sideName(r.Game.Board().BlackTurn) != r.Human
+}
+
+func (r *REPL) prompt() string {
+	b := r.Game.Board()
+	if r.over() {
+		return "> "
+	}
+	if r.Time.Limited() {
+		c := r.clocks[turn(b)].Running(time.Since(r.turnStart))
+		return fmt.Sprintf("%s %s> ", title(sideName(b.BlackTurn)), formatClock(c))
+	}
+	return title(sideName(b.BlackTurn)) + "> "
+}
+
+// show draws the board, the moves the human may play and the score.
+func (r *REPL) show() {
+	b := r.Game.Board()
+	var hints, last uint64
+	if !r.over() && !r.botToMove() {
+		hints = b.LegalMoves()
+	}
+	if x, y, ok := r.Game.LastMove(); ok {
+		last = board.SqureToBit(x, y)
+	}
+
+	fmt.Fprintln(r.out)
+	b.Draw(r.out, r.Color, hints, last)
+
+	black, white := b.Count()
+	if r.Time.Limited() {
+		fmt.Fprintln(r.out, r.clockText())
+	}
+	if !r.over() {
+		fmt.Fprintf(r.out, "Black %d, White %d. %s to move.\n", black, white, title(sideName(b.BlackTurn)))
+		return
+	}
+	switch {
+	case r.flagged != "":
+		fmt.Fprintf(r.out, "Game over: %s wins on time\n", title(otherSide(r.flagged)))
+	case black > white:
+		fmt.Fprintf(r.out, "Game over: Black wins %d-%d\n", black, white)
+	case white > black:
+		fmt.Fprintf(r.out, "Game over: White wins %d-%d\n", black, white)
+	default:
+		fmt.Fprintf(r.out, "Game over: draw %d-%d\n", black, white)
+	}
+}
+
+// play makes the human's move and says if the other side had to pass.
+func (r *REPL) play(move string) error {
+	before := r.Game.Board()
+	if r.over() {
+		return errors.New("the game is over")
+	}
+	if err := r.Game.Play(move); err != nil {
+		return err
+	}
+	r.stopClock(before)
+	r.passed(before)
+	r.show()
+	return nil
+}
+
+func (r *REPL) botMove() error {
+	before := r.Game.Board()
+	side := title(sideName(before.BlackTurn))
+	fmt.Fprintf(r.out, "%s is thinking…\n", side)
+
+	ctx, cancel := context.Background(), context.CancelFunc(func() {})
+	clock := r.clocks[turn(before)]
+	if clock.Limited() {
+		ctx, cancel = context.WithTimeout(ctx, clock.Available())
+	}
+	defer cancel()
+
+	b := before
+	x, y, ok := board.GetMoveTimed(ctx, r.bot, &b, clock)
+	if !r.stopClock(before) {
+		return nil // the move came too late
+	}
+	if !ok {
+		return fmt.Errorf("%s (%s) found no move", side, r.Bot.Name)
+	}
+	if err := r.Game.PlayXY(x, y); err != nil {
+		return fmt.Errorf("%s (%s) played %s: %w", side, r.Bot.Name, board.SquareName(x, y), err)
+	}
+	fmt.Fprintf(r.out, "%s plays %s\n", side, board.SquareName(x, y))
+	r.passed(before)
+	return nil
+}
+
+// passed reports a pass by the side that was to answer the move just
+// made from before.
+func (r *REPL) passed(before board.Board) {
+	after := r.Game.Board()
+	if !after.GameOver() && after.BlackTurn == before.BlackTurn {
+		fmt.Fprintf(r.out, "%s has no move and passes\n", title(sideName(!before.BlackTurn)))
+	}
+}
+
+func (r *REPL) moves() {
+	b := r.Game.Board()
+	if b.GameOver() {
+		fmt.Fprintln(r.out, "The game is over")
+		return
+	}
+	var names []string
+	for moves := b.LegalMoves(); moves != 0; moves &= moves - 1 {
+		names = append(names, board.SquareName(board.BitToSquare(moves&-moves)))
+	}
+	fmt.Fprintln(r.out, strings.Join(names, " "))
+}
+
+// undo takes back moves until the human is to move, so the bot's reply
+// goes with the move it answered.
+func (r *REPL) undo() error {
+	if r.flagged != "" {
+		return fmt.Errorf("%s has lost on time", title(r.flagged))
+	}
+	if !r.Game.Undo() {
+		return errors.New("nothing to undo")
+	}
+	for r.botToMove() && r.Game.Undo() {
+	}
+	// Taking back a bot's first move leaves it to move again. Clocks
+	// carry on from where they were.
+	r.turnStart = time.Now()
+	r.show()
+	return nil
+}
+
+func (r *REPL) analyze() (board.Board, board.Analysis, error) {
+	b := r.Game.Board()
+	if r.over() {
+		return b, board.Analysis{}, errors.New("the game is over")
+	}
+	a, err := board.Analyze(context.Background(), b, r.Depth, 0)
+	return b, a, err
+}
+
+func (r *REPL) hint() error {
+	_, a, err := r.analyze()
+	if err != nil {
+		return err
+	}
+	if len(a.Moves) == 0 {
+		return errors.New("no move to suggest")
+	}
+	best := a.Moves[0]
+	fmt.Fprintf(r.out, "Try %s (%+d)\n", board.SquareName(best.X, best.Y), best.Score)
+	return nil
+}
+
+func (r *REPL) eval() error {
+	b, a, err := r.analyze()
+	if err != nil {
+		return err
+	}
+	PrintAnalysis(r.out, b, a)
+	return nil
+}
+
+func (r *REPL) save(name string) error {
+	if err := os.WriteFile(name, []byte(board.FormatRecord(r.Game, name)), 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(r.out, "Saved %s\n", name)
+	return nil
+}
+
+func (r *REPL) load(name string) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	g, err := board.ParseRecord(string(data))
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	// The bot goes on drawing from the seed it started with.
+	g.Seed = r.Game.Seed
+	*r.Game = *g
+	r.startClocks()
+	r.show()
+	return nil
+}
+
+// flip gives the human the bot's side, the bot taking the human's.
+func (r *REPL) flip() error {
+	if r.Bot == nil {
+		return errors.New("there is no bot to swap sides with")
+	}
+	r.closeBot()
+	r.Human = otherSide(r.Human)
+	r.newBot()
+	fmt.Fprintf(r.out, "You now play %s\n", r.Human)
+	r.show()
+	return nil
+}
+
+// PrintAnalysis lists the moves of b with their scores, best first, and
+// the line the search expects.
+func PrintAnalysis(w io.Writer, b board.Board, a board.Analysis) {
+	side := title(sideName(b.BlackTurn))
+	switch {
+	case b.GameOver():
+		black, white := b.Count()
+		fmt.Fprintf(w, "Game over %d-%d\n", black, white)
+		return
+	case a.Exact:
+		fmt.Fprintf(w, "%s to move, solved; scores are final disc differences\n", side)
+	default:
+		fmt.Fprintf(w, "%s to move, depth %d\n", side, a.Depth)
+	}
+
+	if len(a.Moves) == 0 {
+		fmt.Fprintf(w, "%s must pass\n", side)
+	}
+	for _, m := range a.Moves {
+		fmt.Fprintf(w, "  %s %+5d\n", board.SquareName(m.X, m.Y), m.Score)
+	}
+	fmt.Fprintln(w, "Best line:", strings.Join(a.PV, " "))
+}
@@ -0,0 +1,129 @@
+package repl
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"Othello-Engine/board"
+	"Othello-Engine/match"
+)
+
+// run plays script through a REPL for g against bot, nil for two humans,
+// and returns what it wrote.
+func run(t *testing.T, g *board.Game, bot string, script ...string) string {
+	t.Helper()
+	var out strings.Builder
+	r := New(g, strings.NewReader(strings.Join(script, "\n")+"\n"), &out)
+	r.Depth = 2
+	if bot != "" {
+		e, err := match.ParseEntrant(bot)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Bot = &e
+	}
+	if err := r.Run(); err != nil {
+		t.Fatalf("Run: %v\n%s", err, out.String())
+	}
+	return out.String()
+}
+
+func TestMovesAndUndo(t *testing.T) {
+	g := board.NewGame(board.NewBoard())
+	out := run(t, g, "", "moves", "f4", "undo", "e3", "a1", "quit")
+
+	if !strings.Contains(out, "e3 f4 c5 d6") {
+		t.Errorf("moves not listed:\n%s", out)
+	}
+	if !strings.Contains(out, "invalid move") {
+		t.Errorf("a1 accepted:\n%s", out)
+	}
+	if got := g.Moves(); len(got) != 1 || got[0] != "e3" {
+		t.Errorf("moves = %v, want [e3]", got)
+	}
+}
+
+func TestBotReplies(t *testing.T) {
+	g := board.NewGame(board.NewBoard())
+	out := run(t, g, "greedy:1", "f4", "undo", "e3")
+
+	if !strings.Contains(out, "White plays ") {
+		t.Errorf("bot did not move:\n%s", out)
+	}
+	// Undo takes back the bot's reply along with the move.
+	if got := g.Moves(); len(got) != 2 || got[0] != "e3" {
+		t.Errorf("moves = %v, want e3 and a reply", got)
+	}
+}
+
+func TestFlip(t *testing.T) {
+	g := board.NewGame(board.NewBoard())
+	out := run(t, g, "greedy:1", "flip")
+
+	if !strings.Contains(out, "You now play white") || !strings.Contains(out, "Black plays ") {
+		t.Errorf("bot did not take black:\n%s", out)
+	}
+	if g.Ply() != 1 {
+		t.Errorf("ply = %d, want 1", g.Ply())
+	}
+	if b := g.Board(); b.BlackTurn {
+		t.Error("black to move after the bot's move")
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	for _, name := range []string{"game.txt", "game.ggf"} {
+		path := filepath.Join(t.TempDir(), name)
+		saved := board.NewGame(board.NewBoard())
+		saved.Seed = 42
+		if out := run(t, saved, "", "e3", "f3", "save "+path); !strings.Contains(out, "Seed 42\n") {
+			t.Errorf("seed not shown:\n%s", out)
+		}
+
+		g := board.NewGame(board.NewBoard())
+		run(t, g, "", "load "+path)
+		if got := strings.Join(g.Moves(), " "); got != "e3 f3" {
+			t.Errorf("%s: loaded %q, want %q", name, got, "e3 f3")
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g, err := board.ParseRecord(string(data)); err != nil {
+			t.Errorf("%s: %v", name, err)
+		} else if g.Seed != 42 {
+			t.Errorf("%s: %q read back with seed %d, want 42", name, data, g.Seed)
+		}
+	}
+}
+
+func TestHintAndEval(t *testing.T) {
+	out := run(t, board.NewGame(board.NewBoard()), "", "hint", "eval")
+	if !strings.Contains(out, "Try ") {
+		t.Errorf("no hint:\n%s", out)
+	}
+	if !strings.Contains(out, "Best line:") {
+		t.Errorf("no analysis:\n%s", out)
+	}
+}
+
+func TestOutOfTime(t *testing.T) {
+	g := board.NewGame(board.NewBoard())
+	var out strings.Builder
+	r := New(g, strings.NewReader("e3\nundo\n"), &out)
+	r.Time = board.TimeControl{Base: time.Nanosecond}
+	if err := r.Run(); err != nil {
+		t.Fatalf("Run: %v\n%s", err, out.String())
+	}
+
+	if !strings.Contains(out.String(), "Black ran out of time") {
+		t.Errorf("no loss on time:\n%s", out.String())
+	}
+	if got := g.Moves(); len(got) != 0 {
+		t.Errorf("moves = %v, want none after the flag fell", got)
+	}
+}
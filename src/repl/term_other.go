@@ -0,0 +1,12 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package repl
+
+import "errors"
+
+// Elsewhere lines are read without editing.
+func isTerminal(fd int) bool { return false }
+
+func makeRaw(fd int) (func(), error) {
+	return nil, errors.New("raw mode not supported")
+}
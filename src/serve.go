@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"embed"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"Othello-Engine/board"
+	"Othello-Engine/server"
+)
+
+//go:embed static
+var static embed.FS
+
+func serve(args []string) error {
+	flags := newFlagSet("serve", "")
+	addr := flags.String("addr", ":8080", "address to listen on")
+	bot := flags.String("bot", "pengwin", "bot to play against: pengwin, greedy or random")
+	botDepth := flags.Int("depth", depth, fmt.Sprintf("bot search depth, 1 to %d; with -time the deepest it goes, 0 for no limit", server.MaxDepth))
+	moveTime := flags.Duration("time", 0, "bot time per move, e.g. 2s, instead of a fixed depth")
+	level := flags.Int("level", 0, fmt.Sprintf("bot level for casual play, 1 to %d, instead of -bot, -depth and -time; 0 for none", board.MaxLevel))
+	color := flags.String("color", "black", "the human's side: black or white")
+	clock := flags.String("clock", "", "each side's clock, BASE[+INC][/BYOYOMI] such as 5m+2s; empty for none")
+	ttl := flags.Duration("ttl", 30*time.Minute, "drop games nobody has watched or played for this long, moving their logs to the expired directory")
+	data := flags.String("data", "games", "directory games are saved in, restored on restart; empty to keep them in memory")
+
+	// Every flag can also come from the environment as OTHELLO_<NAME>; the
+	// command line wins.
+	var envErr error
+	flags.VisitAll(func(f *flag.Flag) {
+		name := "OTHELLO_" + strings.ToUpper(f.Name)
+		if v, ok := os.LookupEnv(name); ok && envErr == nil {
+			if err := f.Value.Set(v); err != nil {
+				envErr = usageError{fmt.Errorf("%s: %w", name, err)}
+			}
+		}
+	})
+	if envErr != nil {
+		return envErr
+	}
+	flags.Parse(args)
+
+	files, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err)
+	}
+
+	srv := server.New(http.FS(files), *botDepth, *ttl)
+	srv.MoveTime = *moveTime
+	srv.Opponent = *bot
+	srv.Level = *level
+	srv.Color = *color
+	if srv.TimeControl, err = board.ParseTimeControl(*clock); err != nil {
+		return usageError{err}
+	}
+	srv.DataDir = *data
+	if err := srv.CheckDefaults(); err != nil {
+		return usageError{err}
+	}
+
+	n, err := srv.Load()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		fmt.Printf("Restored %d games from %s\n", n, *data)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	httpSrv := &http.Server{Addr: *addr, Handler: srv}
+	// Event streams only end when the games close, so close them as soon
+	// as shutdown starts rather than after it.
+	httpSrv.RegisterOnShutdown(func() {
+		if err := srv.Close(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	})
+
+	errc := make(chan error, 1)
+	go func() { errc <- httpSrv.ListenAndServe() }()
+	fmt.Printf("Server started at http://%s\n", displayAddr(*addr))
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	fmt.Println("Shutting down")
+	timeout, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return httpSrv.Shutdown(timeout)
+}
+
+// displayAddr turns a listen address such as ":8080" into one to browse to.
+func displayAddr(addr string) string {
+	if strings.HasPrefix(addr, ":") {
+		return "localhost" + addr
+	}
+	return addr
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"Othello-Engine/board"
+)
+
+// AnalyzeRequest is the body of POST /analyze. The position is given
+// either as Position or as bitboards like those of BoardResponse.
+type AnalyzeRequest struct {
+	Position  string `json:"position"` // squares a1..h8 as '-', '*' or 'O', then the side to move
+	Black     string `json:"black"`
+	White     string `json:"white"`
+	BlackTurn bool   `json:"black_turn"`
+
+	Depth    int `json:"depth"`        // search depth, 1 to MaxDepth
+	MoveTime int `json:"move_time_ms"` // search time, up to MaxMoveTime; Depth then caps the search
+}
+
+type MoveScore struct {
+	Move  string `json:"move"`
+	Score int    `json:"score"`
+}
+
+// AnalyzeResponse scores every legal move from the side to move's point of
+// view. Exact scores are final disc differences from the endgame solver;
+// the others are the engine's estimates.
+type AnalyzeResponse struct {
+	Position string      `json:"position"`
+	Moves    []MoveScore `json:"moves"`
+	PV       []string    `json:"pv"`
+	Depth    int         `json:"depth"`
+	Exact    bool        `json:"exact"`
+}
+
+// position reads the board of an analysis request.
+func (req AnalyzeRequest) position() (board.Board, error) {
+	if req.Position != "" {
+		return board.ParsePosition(req.Position)
+	}
+
+	black, err := strconv.ParseUint(req.Black, 10, 64)
+	if err != nil {
+		return board.Board{}, fmt.Errorf("black: %w", err)
+	}
+	white, err := strconv.ParseUint(req.White, 10, 64)
+	if err != nil {
+		return board.Board{}, fmt.Errorf("white: %w", err)
+	}
+	if black&white != 0 {
+		return board.Board{}, errors.New("black and white share squares")
+	}
+	return board.Board{Black: black, White: white, BlackTurn: req.BlackTurn}, nil
+}
+
+func (s *Server) analyzeHandler(w http.ResponseWriter, r *http.Request) {
+	var req AnalyzeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "invalid request: "+err.Error())
+		return
+	}
+
+	b, err := req.position()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidOption, "invalid position: "+err.Error())
+		return
+	}
+
+	depth, moveTime := req.Depth, time.Duration(req.MoveTime)*time.Millisecond
+	if depth == 0 && moveTime == 0 {
+		depth, moveTime = s.Depth, s.MoveTime
+	}
+	if moveTime < 0 || moveTime > MaxMoveTime {
+		writeError(w, http.StatusBadRequest, CodeInvalidOption, fmt.Sprintf("move time must be at most %v", MaxMoveTime))
+		return
+	}
+	if depth > MaxDepth || depth < 1 && !(depth == 0 && moveTime > 0) {
+		writeError(w, http.StatusBadRequest, CodeInvalidOption, fmt.Sprintf("depth must be between 1 and %d", MaxDepth))
+		return
+	}
+
+	// The search stops if the client goes away.
+	analysis, err := board.Analyze(r.Context(), b, depth, moveTime)
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, CodeUnavailable, err.Error())
+		return
+	}
+
+	resp := AnalyzeResponse{
+		Position: board.Position(b),
+		Moves:    make([]MoveScore, 0, len(analysis.Moves)),
+		PV:       analysis.PV,
+		Depth:    analysis.Depth,
+		Exact:    analysis.Exact,
+	}
+	if resp.PV == nil {
+		resp.PV = []string{}
+	}
+	for _, m := range analysis.Moves {
+		resp.Moves = append(resp.Moves, MoveScore{Move: board.SquareName(m.X, m.Y), Score: m.Score})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
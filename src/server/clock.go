@@ -0,0 +1,123 @@
+package server
+
+import (
+	"time"
+
+	"Othello-Engine/board"
+)
+
+// Clocks run once a session is started. A session being restored replays
+// its log with them stopped, taking the time left from the log instead.
+
+func turn(b board.Board) int {
+	if b.BlackTurn {
+		return 0
+	}
+	return 1
+}
+
+// timed reports whether the game has a time control. s.mu must be held.
+func (s *Session) timed() bool {
+	return s.clocks[0].Limited()
+}
+
+// running reports whether the clock of the side to move is running. s.mu
+// must be held.
+func (s *Session) running() bool {
+	return !s.turnStart.IsZero()
+}
+
+// clock is the clock of the side to move as it reads now. s.mu must be
+// held.
+func (s *Session) clock() board.Clock {
+	c := s.clocks[turn(s.game)]
+	if s.running() {
+		c = c.Running(time.Since(s.turnStart))
+	}
+	return c
+}
+
+// resetClocks stops the clocks and sets them to the time control of the
+// game's options. s.mu must be held.
+func (s *Session) resetClocks() {
+	tc, _ := board.ParseTimeControl(s.Options.TimeControl) // checked by resolve
+	s.stopTimer()
+	s.clocks = [2]board.Clock{board.NewClock(tc), board.NewClock(tc)}
+	s.turnStart = time.Time{}
+	s.flagged = ""
+}
+
+// startClock starts the clock of the side to move, with a timer that
+// flags it when its time is up. Without a time control, or once the game
+// is over, the clocks stay stopped. s.mu must be held.
+func (s *Session) startClock() {
+	s.stopTimer()
+	s.turnStart = time.Time{}
+	if !s.timed() || s.over() || s.closed {
+		return
+	}
+
+	s.turnStart = time.Now()
+	black := s.game.BlackTurn
+	var t *time.Timer
+	t = time.AfterFunc(s.clocks[turn(s.game)].Available(), func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.timer == t && !s.over() && !s.closed {
+			s.flag(black)
+		}
+	})
+	s.timer = t
+}
+
+func (s *Session) stopTimer() {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+}
+
+// charge stops the clock of the side to move for the move it is making.
+// If the move came too late the side loses on time and charge reports
+// false. s.mu must be held.
+func (s *Session) charge() bool {
+	if !s.running() {
+		return true
+	}
+	if s.clocks[turn(s.game)].Spend(time.Since(s.turnStart)) {
+		return true
+	}
+	s.flag(s.game.BlackTurn)
+	return false
+}
+
+// flag ends the game as a loss on time for black or white. s.mu must be
+// held.
+func (s *Session) flag(black bool) {
+	s.stopBot()
+	s.stopTimer()
+	s.turnStart = time.Time{}
+	s.clocks[turn(board.Board{BlackTurn: black})].Left = 0
+	s.flagged = sideName(black)
+	s.record(Entry{Type: "timeout"})
+
+	blackDiscs, whiteDiscs := s.game.Count()
+	s.publish(Event{Type: "gameover", Side: s.flagged, BlackDiscs: blackDiscs, WhiteDiscs: whiteDiscs, Winner: s.winner()})
+}
+
+// clocksState is the clocks for a response, nil in an untimed game. s.mu
+// must be held.
+func (s *Session) clocksState() *Clocks {
+	if !s.timed() {
+		return nil
+	}
+
+	live := s.clocks
+	c := &Clocks{Byoyomi: live[0].Byoyomi.Milliseconds()}
+	if s.running() {
+		live[turn(s.game)] = s.clock()
+		c.Running = sideName(s.game.BlackTurn)
+	}
+	c.Black, c.White = live[0].Left.Milliseconds(), live[1].Left.Milliseconds()
+	return c
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"Othello-Engine/board"
+)
+
+// Error codes for failures that are not about the move itself; those use
+// board.MoveErrorCode.
+const (
+	CodeInvalidRequest   = "invalid_request"    // the body is not the JSON expected
+	CodeInvalidOption    = "invalid_option"     // a new game option is out of range
+	CodeUnknownGame      = "unknown_game"       // no session with that ID
+	CodeMethodNotAllowed = "method_not_allowed" // wrong HTTP method for the endpoint
+	CodeUnavailable      = "unavailable"        // the server is shutting down
+	CodeNothingToUndo    = "nothing_to_undo"    // the human has not moved yet
+	CodeInternal         = "internal"
+)
+
+// ErrorResponse is the body of every error reply.
+type ErrorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{Code: code, Message: message})
+}
+
+// writeMoveError replies to a rejected move, undo or resignation with a
+// status that matches its cause: malformed input, an illegal square, or a
+// game not expecting it.
+func writeMoveError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrClosed):
+		writeError(w, http.StatusServiceUnavailable, CodeUnavailable, err.Error())
+		return
+	case errors.Is(err, ErrNothingToUndo):
+		writeError(w, http.StatusConflict, CodeNothingToUndo, err.Error())
+		return
+	}
+
+	var invalid *board.InvalidMoveError
+	if !errors.As(err, &invalid) {
+		writeError(w, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
+
+	status := http.StatusBadRequest
+	switch invalid.Code {
+	case board.CodeOccupied, board.CodeNoFlips:
+		status = http.StatusUnprocessableEntity
+	case board.CodeNotYourTurn, board.CodeGameOver, board.CodeNoLegalMove, board.CodeOutOfTime:
+		status = http.StatusConflict
+	}
+	writeError(w, status, string(invalid.Code), invalid.Error())
+}
+
+// allow serves only requests using method (GET also admits HEAD).
+func allow(method string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method && !(method == http.MethodGet && r.Method == http.MethodHead) {
+			w.Header().Set("Allow", method)
+			writeError(w, http.StatusMethodNotAllowed, CodeMethodNotAllowed, r.Method+" not allowed, use "+method)
+			return
+		}
+		h(w, r)
+	}
+}
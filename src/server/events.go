@@ -0,0 +1,203 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/bits"
+	"net/http"
+
+	"Othello-Engine/board"
+)
+
+// Event is pushed to clients subscribed to a game. State is the position
+// after the event.
+type Event struct {
+	Type    string   `json:"type"`           // "state", "move", "pass", "thinking", "progress", "undo", "new" or "gameover"
+	Side    string   `json:"side,omitempty"` // for "gameover", the side that resigned or ran out of time
+	Move    string   `json:"move,omitempty"`
+	Flipped []string `json:"flipped,omitempty"`
+
+	Done  int `json:"done,omitempty"` // root moves searched, for "progress"
+	Total int `json:"total,omitempty"`
+
+	BlackDiscs int    `json:"black_discs"` // final score, for "gameover"
+	WhiteDiscs int    `json:"white_discs"`
+	Winner     string `json:"winner,omitempty"` // "black", "white" or "draw"
+
+	State BoardResponse `json:"state"`
+}
+
+func sideName(black bool) string {
+	if black {
+		return "black"
+	}
+	return "white"
+}
+
+// winner names the side ahead on discs, or "draw".
+func winner(b board.Board) string {
+	black, white := b.Count()
+	switch {
+	case black > white:
+		return "black"
+	case white > black:
+		return "white"
+	}
+	return "draw"
+}
+
+func squareNames(mask uint64) []string {
+	names := make([]string, 0, bits.OnesCount64(mask))
+	for ; mask != 0; mask &= mask - 1 {
+		x, y := board.BitToSquare(mask & -mask)
+		names = append(names, board.SquareName(x, y))
+	}
+	return names
+}
+
+// Subscribe returns a channel receiving the session's events and a
+// function to stop them. The channel is closed if the subscriber falls
+// far behind, since it has then missed events, and when the session is
+// closed. A session with subscribers does not expire.
+func (s *Session) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, 64)
+
+	s.mu.Lock()
+	s.subscribers[ch] = struct{}{}
+	s.mu.Unlock()
+
+	return ch, func() {
+		s.mu.Lock()
+		s.unsubscribe(ch)
+		s.mu.Unlock()
+	}
+}
+
+// unsubscribe removes ch and closes it, unless it is already gone. s.mu
+// must be held.
+func (s *Session) unsubscribe(ch chan Event) {
+	if _, ok := s.subscribers[ch]; ok {
+		delete(s.subscribers, ch)
+		close(ch)
+	}
+}
+
+// publish sends ev with the current position to every subscriber. A
+// subscriber whose buffer is full is cut off rather than left to miss
+// the event. s.mu must be held.
+func (s *Session) publish(ev Event) {
+	s.touch()
+	ev.State = s.state()
+	for ch := range s.subscribers {
+		select {
+		case ch <- ev:
+		default:
+			s.unsubscribe(ch)
+		}
+	}
+}
+
+// play makes a move for the side to move, stopping its clock and starting
+// the other, and publishes what happened. A move made after the mover's
+// time ran out loses the game. s.mu must be held.
+func (s *Session) play(x, y int) error {
+	mover := s.game.BlackTurn
+	flipped := s.game.Flips(x, y)
+	before := s.position
+
+	next := s.game
+	if err := next.PlayXY(x, y); err != nil {
+		return err
+	}
+	if !s.charge() {
+		return ErrOutOfTime
+	}
+	s.game = next
+
+	s.history = append(s.history, before)
+	e := Entry{Type: "move", Move: board.SquareName(x, y)}
+	if s.timed() {
+		e.Clock = s.clocks[turn(before.game)].Left.Milliseconds()
+	}
+	if s.source != nil {
+		e.Draws = s.source.drawn()
+	}
+	s.record(e)
+
+	s.lastMove = board.SquareName(x, y)
+	s.lastFlipped = flipped
+	s.passed = ""
+	if !s.game.GameOver() && s.game.BlackTurn == mover {
+		s.passed = sideName(!mover)
+	}
+	if s.running() {
+		s.startClock()
+	}
+
+	s.publish(Event{Type: "move", Side: sideName(mover), Move: s.lastMove, Flipped: squareNames(flipped)})
+
+	switch {
+	case s.game.GameOver():
+		black, white := s.game.Count()
+		s.publish(Event{Type: "gameover", BlackDiscs: black, WhiteDiscs: white, Winner: winner(s.game)})
+	case s.passed != "":
+		s.publish(Event{Type: "pass", Side: s.passed})
+	}
+	return nil
+}
+
+// progress reports how far the bot's search has got.
+func (s *Session) progress(done, total int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.publish(Event{Type: "progress", Side: sideName(s.game.BlackTurn), Done: done, Total: total})
+}
+
+func (s *Server) events(w http.ResponseWriter, r *http.Request, id string) {
+	session, ok := s.find(w, id)
+	if !ok {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, CodeInternal, "streaming unsupported")
+		return
+	}
+
+	events, stop := session.Subscribe()
+	defer stop()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	send := func(ev Event) {
+		data, _ := json.Marshal(ev)
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		flusher.Flush()
+	}
+
+	send(Event{Type: "state", State: session.State()})
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				// Fell behind, or the game was closed; ending the
+				// stream makes the client reconnect and start again.
+				return
+			}
+			send(ev)
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *Server) eventsHandler(w http.ResponseWriter, r *http.Request) {
+	s.events(w, r, r.PathValue("id"))
+}
+
+func (s *Server) legacyEventsHandler(w http.ResponseWriter, r *http.Request) {
+	s.events(w, r, DefaultID)
+}
@@ -0,0 +1,222 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"Othello-Engine/board"
+)
+
+// Entry is one line of a game's log. A log starts with a "new" entry and
+// then has one "move" entry per move played, by either side, with "undo",
+// "resign", "timeout" and further "new" entries where those happened.
+type Entry struct {
+	Type string    `json:"type"` // "new", "move", "undo", "resign" or "timeout"
+	Time time.Time `json:"time"`
+
+	Game  *NewGameRequest `json:"game,omitempty"`     // for "new": the options, defaults filled in
+	Start string          `json:"start,omitempty"`    // for "new": the start position as GGF
+	Move  string          `json:"move,omitempty"`     // for "move"
+	Clock int64           `json:"clock_ms,omitempty"` // for "move" in a timed game: the mover's main time left after it
+	Draws int64           `json:"draws,omitempty"`    // for "move": the numbers the bot had drawn from its seed by then
+}
+
+func logPath(dir, id string) string {
+	return filepath.Join(dir, id+".jsonl")
+}
+
+// persist starts the log of a new session in s.DataDir, if set. A game
+// that can't be logged is still played, just not restored. It must be
+// called before the session is shared.
+func (s *Server) persist(session *Session) {
+	if s.DataDir == "" {
+		return
+	}
+
+	f, err := os.Create(logPath(s.DataDir, session.ID))
+	if err != nil {
+		log.Printf("game %s not saved: %v", session.ID, err)
+		return
+	}
+	session.log = f
+
+	opts := session.Options
+	if err := session.append(Entry{Type: "new", Game: &opts, Start: board.GGF(session.game)}); err != nil {
+		log.Printf("game %s not saved: %v", session.ID, err)
+		f.Close()
+		os.Remove(f.Name())
+		session.log = nil
+	}
+}
+
+// append writes e to the session's log, if it has one. s.mu must be held
+// once the session is shared.
+func (s *Session) append(e Entry) error {
+	if s.log == nil {
+		return nil
+	}
+	e.Time = time.Now()
+	return json.NewEncoder(s.log).Encode(e)
+}
+
+// record appends e to the session's log, reporting rather than returning
+// a failure since the game itself goes on. s.mu must be held.
+func (s *Session) record(e Entry) {
+	if err := s.append(e); err != nil {
+		log.Printf("game %s: %v", s.ID, err)
+	}
+}
+
+// Load restores the games logged in s.DataDir. A log cut short by a crash
+// is replayed, and trimmed, up to its last complete entry.
+func (s *Server) Load() (int, error) {
+	if s.DataDir == "" {
+		return 0, nil
+	}
+	if err := os.MkdirAll(s.DataDir, 0o755); err != nil {
+		return 0, err
+	}
+
+	files, err := os.ReadDir(s.DataDir)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, file := range files {
+		id, ok := strings.CutSuffix(file.Name(), ".jsonl")
+		if !ok || file.IsDir() {
+			continue
+		}
+
+		session, err := s.restore(id)
+		if err != nil {
+			log.Printf("skipping game %s: %v", id, err)
+			continue
+		}
+		s.Store.Add(session)
+		session.Start()
+		n++
+	}
+	return n, nil
+}
+
+// restore replays the log of one game.
+func (s *Server) restore(id string) (*Session, error) {
+	path := logPath(s.DataDir, id)
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var session *Session
+	var good int64 // bytes replayed
+	trim := false
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			if session == nil {
+				return nil, err
+			}
+			log.Printf("game %s: stopping at line %d: %v", id, line, err)
+			trim = true
+			break
+		}
+
+		if session == nil {
+			if e.Type != "new" || e.Game == nil {
+				return nil, fmt.Errorf("log does not start with a new game")
+			}
+			start, err := board.ParseGGF(e.Start)
+			if err != nil {
+				return nil, err
+			}
+			if session, err = s.buildSession(id, *e.Game, start); err != nil {
+				return nil, err
+			}
+		} else if err := s.replay(session, e); err != nil {
+			log.Printf("game %s: stopping at line %d: %v", id, line, err)
+			trim = true
+			break
+		}
+		good += int64(len(scanner.Bytes())) + 1
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, fmt.Errorf("empty log")
+	}
+
+	// New entries must not follow a broken one.
+	if trim {
+		if err := os.Truncate(path, good); err != nil {
+			return nil, err
+		}
+	}
+	if session.log, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// replay applies a logged entry to a session that is not shared yet.
+func (s *Server) replay(session *Session, e Entry) error {
+	switch e.Type {
+	case "move":
+		x, y, err := board.ParseSquare(e.Move)
+		if err != nil {
+			return err
+		}
+		mover := turn(session.game)
+		if err := session.play(x, y); err != nil {
+			return err
+		}
+		if session.timed() {
+			session.clocks[mover].Left = time.Duration(e.Clock) * time.Millisecond
+		}
+		// The bot's moves are not searched again, so its source is moved
+		// on to where the bot had left it.
+		if session.source != nil {
+			session.source.skip(e.Draws)
+		}
+		return nil
+	case "undo":
+		return session.undo()
+	case "resign":
+		if session.over() {
+			return ErrGameOver
+		}
+		session.resign()
+		return nil
+	case "timeout":
+		if session.over() {
+			return ErrGameOver
+		}
+		session.flag(session.game.BlackTurn)
+		return nil
+	case "new":
+		if e.Game == nil {
+			return fmt.Errorf("new game without options")
+		}
+		start, err := board.ParseGGF(e.Start)
+		if err != nil {
+			return err
+		}
+		human, bot, src, err := players(session, *e.Game)
+		if err != nil {
+			return err
+		}
+		session.reset(human, bot, src, *e.Game, start)
+		return nil
+	}
+	return fmt.Errorf("unknown entry %q", e.Type)
+}
@@ -0,0 +1,536 @@
+// Package server serves the web client and the game API. Every game is a
+// session with its own ID; the legacy /move and /state endpoints act on
+// the "default" session so the bundled client keeps working.
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"Othello-Engine/board"
+)
+
+const DefaultID = "default"
+
+type MoveRequest struct {
+	Move string `json:"move"`
+}
+
+// BoardResponse is the state of a game. Black and White are the bitboards
+// as decimal strings; the other fields save clients from decoding them.
+type BoardResponse struct {
+	ID        string `json:"id,omitempty"`
+	Black     string `json:"black"`
+	White     string `json:"white"`
+	BlackTurn bool   `json:"black_turn"`
+
+	LegalMoves []string `json:"legal_moves"`
+	LastMove   string   `json:"last_move,omitempty"`
+	Flipped    []string `json:"flipped,omitempty"`  // discs turned by the last move
+	Passed     string   `json:"passed,omitempty"`   // side that had to pass after the last move
+	Resigned   string   `json:"resigned,omitempty"` // side that resigned, ending the game
+	Flagged    string   `json:"flagged,omitempty"`  // side that ran out of time, ending the game
+	Clocks     *Clocks  `json:"clocks,omitempty"`   // in a game with a time control
+	BlackDiscs int      `json:"black_discs"`
+	WhiteDiscs int      `json:"white_discs"`
+	GameOver   bool     `json:"game_over"`
+	Winner     string   `json:"winner,omitempty"` // "black", "white" or "draw" once the game is over
+	Seed       int64    `json:"seed,omitempty"`   // the game's, as in NewGameRequest
+}
+
+// Clocks is the time each side has left, in milliseconds, as of the
+// response. Black and White are main time.
+type Clocks struct {
+	Black   int64  `json:"black_ms"`
+	White   int64  `json:"white_ms"`
+	Byoyomi int64  `json:"byoyomi_ms,omitempty"` // for each move once main time is spent
+	Running string `json:"running,omitempty"`    // the side whose clock is running
+}
+
+// NewGameRequest is the body of POST /games. Zero values pick the same
+// game as the default session.
+type NewGameRequest struct {
+	Color    string `json:"color"`        // the human's side: "black" or "white"
+	Opponent string `json:"opponent"`     // "pengwin", "greedy" or "random"
+	Depth    int    `json:"depth"`        // bot search depth, 1 to MaxDepth
+	MoveTime int    `json:"move_time_ms"` // bot time per move, up to MaxMoveTime; Depth then caps the search
+	Opening  string `json:"opening"`      // "" for the normal start, "random" for a balanced random one
+	Position string `json:"position"`     // a start position set up by hand, as in AnalyzeRequest, instead of an opening
+
+	// Level, from 1 to board.MaxLevel, picks a bot of that strength for
+	// casual play instead of Opponent, Depth and MoveTime.
+	Level int `json:"level"`
+
+	// TimeControl is each side's clock, as board.ParseTimeControl reads
+	// it, such as "5m+2s"; "" for none. Running out of time loses.
+	TimeControl string `json:"time_control"`
+
+	// Seed makes the random opening and the bot's random choices; 0 picks
+	// one. It is logged with the game, so the same options play the same
+	// game against the same moves.
+	Seed int64 `json:"seed"`
+}
+
+const (
+	MaxDepth    = 10
+	MaxMoveTime = time.Minute
+)
+
+// Server options set the game of the default session and fill in those a
+// new game request leaves out.
+type Server struct {
+	Store    *Store
+	Depth    int           // bot depth
+	MoveTime time.Duration // bot time per move, searching by time instead of to Depth
+	Opponent string        // bot kind, as in NewGameRequest
+	Level    int           // bot level, as in NewGameRequest, instead of the three above
+	Color    string        // the human's side
+
+	TimeControl board.TimeControl // each side's clock
+
+	// DataDir, if set, is where games are logged so Load can restore them
+	// after a restart.
+	DataDir string
+
+	mux  *http.ServeMux
+	done chan struct{} // closed by Close
+}
+
+// New returns a server for the static client in static. Sessions idle for
+// longer than ttl are dropped.
+func New(static http.FileSystem, depth int, ttl time.Duration) *Server {
+	s := &Server{Store: NewStore(ttl), Depth: depth, mux: http.NewServeMux(), done: make(chan struct{})}
+
+	s.mux.Handle("/", http.FileServer(static))
+	s.mux.HandleFunc("/move", allow(http.MethodPost, s.legacyMoveHandler))
+	s.mux.HandleFunc("/state", allow(http.MethodGet, s.legacyStateHandler))
+	s.mux.HandleFunc("/events", allow(http.MethodGet, s.legacyEventsHandler))
+
+	s.mux.HandleFunc("/games", allow(http.MethodPost, s.newGameHandler))
+	s.mux.HandleFunc("/games/{id}/move", allow(http.MethodPost, s.moveHandler))
+	s.mux.HandleFunc("/games/{id}/state", allow(http.MethodGet, s.stateHandler))
+	s.mux.HandleFunc("/games/{id}/events", allow(http.MethodGet, s.eventsHandler))
+	s.mux.HandleFunc("/games/{id}/undo", allow(http.MethodPost, s.undoHandler))
+	s.mux.HandleFunc("/games/{id}/new", allow(http.MethodPost, s.restartHandler))
+	s.mux.HandleFunc("/games/{id}/resign", allow(http.MethodPost, s.resignHandler))
+
+	s.mux.HandleFunc("/analyze", allow(http.MethodPost, s.analyzeHandler))
+
+	if ttl > 0 {
+		go s.expire(ttl / 4)
+	}
+	go openingBook() // ready before the first game asks for it
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Close ends the event streams, stops the bots and closes the game logs.
+// Games in progress can be loaded again by a new server.
+func (s *Server) Close() error {
+	select {
+	case <-s.done:
+		return nil
+	default:
+		close(s.done)
+	}
+	return s.Store.Close()
+}
+
+// expire drops idle games every so often until the server is closed.
+func (s *Server) expire(every time.Duration) {
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case now := <-t.C:
+			s.Store.Expire(now)
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// newBot builds the bot for a game; progress receives its search progress
+// and r makes its random choices. With a move time, a depth of 0 leaves
+// the search unbounded.
+func newBot(opponent string, depth int, moveTime time.Duration, side string, progress func(done, total int), r *rand.Rand) (board.Player, error) {
+	if moveTime < 0 || moveTime > MaxMoveTime {
+		return nil, fmt.Errorf("move time must be at most %v", MaxMoveTime)
+	}
+	if depth > MaxDepth || depth < 1 && !(depth == 0 && moveTime > 0) {
+		return nil, fmt.Errorf("depth must be between 1 and %d", MaxDepth)
+	}
+
+	switch opponent {
+	case "", "pengwin":
+		bot := board.NewPengwin(depth, side)
+		bot.Time = moveTime
+		bot.Progress = progress
+		bot.Rand = r
+		return bot, nil
+	case "greedy":
+		bot := board.NewGreedy(depth, side)
+		bot.Time = moveTime
+		bot.Progress = progress
+		bot.Rand = r
+		return bot, nil
+	case "random":
+		return board.RandomPlayer{Rand: r}, nil
+	}
+	return nil, fmt.Errorf("unknown opponent %q", opponent)
+}
+
+// newSession builds a session; the caller registers and starts it.
+func (s *Server) newSession(id string, req NewGameRequest) (*Session, error) {
+	opts, start, err := s.resolve(req)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildSession(id, opts, start)
+}
+
+// resolve fills in the options a request leaves out and picks its start
+// position.
+func (s *Server) resolve(req NewGameRequest) (NewGameRequest, board.Board, error) {
+	if req.Color == "" {
+		req.Color = s.Color
+	}
+
+	// A request naming a level or any bot option gets only what it asked
+	// for.
+	if req.Level == 0 && req.Opponent == "" && req.Depth == 0 && req.MoveTime == 0 {
+		req.Level = s.Level
+	}
+	if req.Level != 0 {
+		if req.Opponent != "" || req.Depth != 0 || req.MoveTime != 0 {
+			return NewGameRequest{}, board.Board{}, fmt.Errorf("choose a level or a bot, not both")
+		}
+	} else {
+		if req.Opponent == "" {
+			req.Opponent = s.Opponent
+		}
+		if req.Depth == 0 && req.MoveTime == 0 {
+			req.Depth, req.MoveTime = s.Depth, int(s.MoveTime/time.Millisecond)
+		}
+	}
+	if req.TimeControl == "" {
+		req.TimeControl = s.TimeControl.String()
+	}
+	if _, err := board.ParseTimeControl(req.TimeControl); err != nil {
+		return NewGameRequest{}, board.Board{}, err
+	}
+	if req.Seed == 0 {
+		req.Seed = board.NewSeed()
+	}
+
+	if req.Position != "" {
+		if req.Opening != "" {
+			return NewGameRequest{}, board.Board{}, fmt.Errorf("choose an opening or a position, not both")
+		}
+		start, err := board.ParsePosition(req.Position)
+		if err == nil {
+			err = start.Validate()
+		}
+		if err != nil {
+			return NewGameRequest{}, board.Board{}, fmt.Errorf("invalid position: %w", err)
+		}
+		return req, start, nil
+	}
+
+	start := board.NewBoard()
+	switch req.Opening {
+	case "":
+	case "random":
+		book, err := openingBook()
+		if err != nil {
+			return NewGameRequest{}, board.Board{}, err
+		}
+		start = book[board.NewRand(req.Seed).Intn(len(book))].Board
+	default:
+		return NewGameRequest{}, board.Board{}, fmt.Errorf("unknown opening %q", req.Opening)
+	}
+	return req, start, nil
+}
+
+// openingBook returns the random openings games are started from, picked
+// by their seed. They are searched for once, the same in every run, rather
+// than for each game a client asks for.
+var openingBook = sync.OnceValues(func() ([]board.Opening, error) {
+	return board.BalancedOpenings(board.NewRand(1), 128, 8, 6, 2)
+})
+
+// buildSession makes a session from resolved options.
+func (s *Server) buildSession(id string, opts NewGameRequest, start board.Board) (*Session, error) {
+	session := NewSession(id, "", nil, start)
+	human, bot, src, err := players(session, opts)
+	if err != nil {
+		return nil, err
+	}
+	session.Human, session.Bot, session.source, session.Options = human, bot, src, opts
+	session.resetClocks()
+	return session, nil
+}
+
+// players works out the human's side and the bot for a game in session,
+// with the source of the bot's random choices.
+func players(session *Session, opts NewGameRequest) (string, board.Player, *lockedSource, error) {
+	human, botSide := "black", "white"
+	switch opts.Color {
+	case "", "black":
+	case "white":
+		human, botSide = "white", "black"
+	default:
+		return "", nil, nil, fmt.Errorf("unknown color %q", opts.Color)
+	}
+
+	// The bot draws from a source of its own, apart from the opening's.
+	src := &lockedSource{src: rand.NewSource(botSeed(opts.Seed)).(rand.Source64)}
+	r := rand.New(src)
+	if opts.Level != 0 {
+		bot, err := board.NewLevel(opts.Level, botSide)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		bot.Progress, bot.Rand = session.progress, r
+		return human, bot, src, nil
+	}
+
+	moveTime := time.Duration(opts.MoveTime) * time.Millisecond
+	bot, err := newBot(opts.Opponent, opts.Depth, moveTime, botSide, session.progress, r)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return human, bot, src, nil
+}
+
+// botSeed derives the seed of the bot's source from the game's, so that
+// the bot does not draw the numbers that made the random opening.
+func botSeed(seed int64) int64 {
+	return seed ^ 0x5bd1e995
+}
+
+// lockedSource is a rand.Source64 that a bot stopped on its way to a move
+// can share with the search that replaces it. It draws the same numbers as
+// board.NewRand, and counts them so that a restored game can pick up where
+// the bot left off.
+type lockedSource struct {
+	mu    sync.Mutex
+	src   rand.Source64
+	draws int64
+}
+
+func (l *lockedSource) Int63() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.draws++
+	return l.src.Int63()
+}
+
+func (l *lockedSource) Uint64() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.draws++
+	return l.src.Uint64()
+}
+
+func (l *lockedSource) Seed(seed int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.src.Seed(seed)
+	l.draws = 0
+}
+
+// drawn returns how many numbers have been drawn.
+func (l *lockedSource) drawn() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.draws
+}
+
+// skip draws numbers until n have been drawn in all.
+func (l *lockedSource) skip(n int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for ; l.draws < n; l.draws++ {
+		l.src.Int63()
+	}
+}
+
+// CheckDefaults reports whether the server options describe a game it can
+// start.
+func (s *Server) CheckDefaults() error {
+	_, err := s.newSession(DefaultID, NewGameRequest{})
+	return err
+}
+
+// session looks up a game, recreating the default one if it expired.
+func (s *Server) session(id string) (*Session, bool) {
+	if id != DefaultID {
+		return s.Store.Get(id)
+	}
+
+	session, err := s.Store.GetOrCreate(DefaultID, func() (*Session, error) {
+		session, err := s.newSession(DefaultID, NewGameRequest{})
+		if err != nil {
+			return nil, err
+		}
+		s.persist(session)
+		return session, nil
+	})
+	if err != nil {
+		return nil, false
+	}
+	session.Start()
+	return session, true
+}
+
+// find looks up a game, replying 404 if there is none.
+func (s *Server) find(w http.ResponseWriter, id string) (*Session, bool) {
+	session, ok := s.session(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, CodeUnknownGame, "unknown game "+id)
+	}
+	return session, ok
+}
+
+func writeState(w http.ResponseWriter, session *Session) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(session.State())
+}
+
+func (s *Server) newGameHandler(w http.ResponseWriter, r *http.Request) {
+	var req NewGameRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, CodeInvalidRequest, "invalid request: "+err.Error())
+			return
+		}
+	}
+
+	session, err := s.newSession(newID(), req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidOption, err.Error())
+		return
+	}
+	s.persist(session)
+	s.Store.Add(session)
+	session.Start()
+
+	w.WriteHeader(http.StatusCreated)
+	writeState(w, session)
+}
+
+func (s *Server) move(w http.ResponseWriter, r *http.Request, id string) {
+	session, ok := s.find(w, id)
+	if !ok {
+		return
+	}
+
+	var req MoveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "invalid request: "+err.Error())
+		return
+	}
+
+	if err := session.Move(req.Move); err != nil {
+		writeMoveError(w, err)
+		return
+	}
+
+	writeState(w, session)
+}
+
+func (s *Server) state(w http.ResponseWriter, r *http.Request, id string) {
+	session, ok := s.find(w, id)
+	if !ok {
+		return
+	}
+
+	writeState(w, session)
+}
+
+func (s *Server) undoHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := s.find(w, r.PathValue("id"))
+	if !ok {
+		return
+	}
+
+	if err := session.Undo(); err != nil {
+		writeMoveError(w, err)
+		return
+	}
+	writeState(w, session)
+}
+
+func (s *Server) resignHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := s.find(w, r.PathValue("id"))
+	if !ok {
+		return
+	}
+
+	if err := session.Resign(); err != nil {
+		writeMoveError(w, err)
+		return
+	}
+	writeState(w, session)
+}
+
+// restartHandler starts a new game in place of an existing one, with the
+// options in the body or else those of the old game.
+func (s *Server) restartHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := s.find(w, r.PathValue("id"))
+	if !ok {
+		return
+	}
+
+	// The same options with a new seed, unless the request brings its own.
+	req := session.options()
+	req.Seed = 0
+	if r.ContentLength != 0 {
+		req = NewGameRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, CodeInvalidRequest, "invalid request: "+err.Error())
+			return
+		}
+	}
+
+	opts, start, err := s.resolve(req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidOption, err.Error())
+		return
+	}
+	human, bot, src, err := players(session, opts)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidOption, err.Error())
+		return
+	}
+
+	if err := session.Reset(human, bot, src, opts, start); err != nil {
+		writeMoveError(w, err)
+		return
+	}
+	writeState(w, session)
+}
+
+func (s *Server) moveHandler(w http.ResponseWriter, r *http.Request) {
+	s.move(w, r, r.PathValue("id"))
+}
+
+func (s *Server) stateHandler(w http.ResponseWriter, r *http.Request) {
+	s.state(w, r, r.PathValue("id"))
+}
+
+func (s *Server) legacyMoveHandler(w http.ResponseWriter, r *http.Request) {
+	s.move(w, r, DefaultID)
+}
+
+func (s *Server) legacyStateHandler(w http.ResponseWriter, r *http.Request) {
+	s.state(w, r, DefaultID)
+}
@@ -0,0 +1,946 @@
+package server
+
+import (
+	"encoding/json"
+	"math/bits"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"Othello-Engine/board"
+	"Othello-Engine/internal/testbot"
+)
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	return New(http.Dir(t.TempDir()), 1, 0)
+}
+
+func do(s *Server, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func decode(t *testing.T, rec *httptest.ResponseRecorder) BoardResponse {
+	t.Helper()
+	var resp BoardResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode %q: %v", rec.Body.String(), err)
+	}
+	return resp
+}
+
+func decodeError(t *testing.T, rec *httptest.ResponseRecorder) ErrorResponse {
+	t.Helper()
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("error Content-Type = %q", ct)
+	}
+	var resp ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode %q: %v", rec.Body.String(), err)
+	}
+	return resp
+}
+
+func discs(t *testing.T, resp BoardResponse) int {
+	t.Helper()
+	black, err := strconv.ParseUint(resp.Black, 10, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	white, err := strconv.ParseUint(resp.White, 10, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bits.OnesCount64(black) + bits.OnesCount64(white)
+}
+
+func session(t *testing.T, s *Server, id string) *Session {
+	t.Helper()
+	session, ok := s.Store.Get(id)
+	if !ok {
+		t.Fatalf("no session %q", id)
+	}
+	return session
+}
+
+// waitTurn polls the state endpoint until it is black's turn (or white's).
+func waitTurn(t *testing.T, s *Server, path string, blackTurn bool) BoardResponse {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		rec := do(s, http.MethodGet, path, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: %d %s", path, rec.Code, rec.Body)
+		}
+		if resp := decode(t, rec); resp.BlackTurn == blackTurn {
+			return resp
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("GET %s: turn never changed", path)
+	return BoardResponse{}
+}
+
+func TestNewGameAndMove(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := do(s, http.MethodPost, "/games", `{"opponent":"random"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST /games: %d %s", rec.Code, rec.Body)
+	}
+	game := decode(t, rec)
+	if game.ID == "" || !game.BlackTurn {
+		t.Fatalf("new game = %+v", game)
+	}
+
+	rec = do(s, http.MethodPost, "/games/"+game.ID+"/move", `{"move":"c5"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("move: %d %s", rec.Code, rec.Body)
+	}
+
+	resp := waitTurn(t, s, "/games/"+game.ID+"/state", true)
+	if got := discs(t, resp); got != 6 {
+		t.Errorf("discs after one move each = %d, want 6", got)
+	}
+}
+
+func TestBotMovesFirstWhenHumanIsWhite(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := do(s, http.MethodPost, "/games", `{"color":"white","opponent":"greedy","depth":1}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST /games: %d %s", rec.Code, rec.Body)
+	}
+	game := decode(t, rec)
+
+	resp := waitTurn(t, s, "/games/"+game.ID+"/state", false)
+	if got := discs(t, resp); got != 5 {
+		t.Errorf("discs after the bot's first move = %d, want 5", got)
+	}
+}
+
+func TestServerOptionsSetDefaultGame(t *testing.T) {
+	s := newTestServer(t)
+	s.Color = "white"
+	s.Opponent = "pengwin"
+	s.Depth = 0
+	s.MoveTime = 20 * time.Millisecond
+	if err := s.CheckDefaults(); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := waitTurn(t, s, "/state", false)
+	if got := discs(t, resp); got != 5 {
+		t.Errorf("discs after the timed bot's first move = %d, want 5", got)
+	}
+
+	s.Opponent = "nboard"
+	if err := s.CheckDefaults(); err == nil {
+		t.Errorf("CheckDefaults accepted an unknown opponent")
+	}
+}
+
+func TestNewGameAtLevel(t *testing.T) {
+	s := newTestServer(t)
+	s.Level = 2
+	if err := s.CheckDefaults(); err != nil {
+		t.Fatal(err)
+	}
+
+	for body, want := range map[string]int{`{}`: 2, `{"level":5,"color":"white"}`: 5} {
+		game := decode(t, do(s, http.MethodPost, "/games", body))
+		session := session(t, s, game.ID)
+		bot, ok := session.Bot.(board.Pengwin)
+		if l := board.Levels[want-1]; !ok || session.Options.Level != want || bot.Depth != l.Depth || bot.Noise != l.Noise {
+			t.Errorf("POST /games %s: level %d, bot %+v", body, session.Options.Level, session.Bot)
+		}
+	}
+
+	// Naming a bot leaves the default level out.
+	game := decode(t, do(s, http.MethodPost, "/games", `{"opponent":"random"}`))
+	if opts := session(t, s, game.ID).Options; opts.Level != 0 || opts.Opponent != "random" {
+		t.Errorf("options = %+v", opts)
+	}
+}
+
+func TestSeedReplaysGame(t *testing.T) {
+	s := newTestServer(t)
+
+	var games [2]BoardResponse
+	for i := range games {
+		game := decode(t, do(s, http.MethodPost, "/games", `{"color":"white","opponent":"random","opening":"random","seed":7}`))
+		if game.Seed != 7 {
+			t.Errorf("seed in state = %d, want 7", game.Seed)
+		}
+		games[i] = waitTurn(t, s, "/games/"+game.ID+"/state", false)
+		games[i].ID = ""
+	}
+	if games[0].Black != games[1].Black || games[0].White != games[1].White {
+		t.Errorf("seed 7 played %+v, then %+v", games[0], games[1])
+	}
+
+	// A new game without options picks a new seed.
+	game := decode(t, do(s, http.MethodPost, "/games", `{"opponent":"random","seed":7}`))
+	do(s, http.MethodPost, "/games/"+game.ID+"/new", "")
+	if seed := session(t, s, game.ID).options().Seed; seed == 7 || seed == 0 {
+		t.Errorf("seed after new = %d", seed)
+	}
+}
+
+func TestNewGameRejectsBadOptions(t *testing.T) {
+	s := newTestServer(t)
+
+	for _, body := range []string{
+		`{"color":"red"}`,
+		`{"opponent":"nboard"}`,
+		`{"depth":99}`,
+		`{"move_time_ms":-1}`,
+		`{"move_time_ms":600000}`,
+		`{"opening":"f5"}`,
+		`{"position":"xyz"}`,
+		`{"position":"` + strings.Repeat("*", 64) + ` O"}`,
+		`{"position":"` + strings.Repeat("-", 64) + ` *"}`,
+		`{"position":"` + board.Position(board.NewBoard()) + `","opening":"random"}`,
+		`{"time_control":"5m+"}`,
+		`{"time_control":"-1m"}`,
+		`{"level":11}`,
+		`{"level":3,"depth":4}`,
+		`not json`,
+	} {
+		if rec := do(s, http.MethodPost, "/games", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("POST /games %s: %d, want %d", body, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestNewGameFromPosition(t *testing.T) {
+	s := newTestServer(t)
+
+	start := board.NewBoard()
+	start.BlackTurn = false
+	rec := do(s, http.MethodPost, "/games", `{"color":"white","position":"`+board.Position(start)+`"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST /games: %d %s", rec.Code, rec.Body)
+	}
+	game := decode(t, rec)
+	if game.BlackTurn || len(game.LegalMoves) != 4 || discs(t, game) != 4 {
+		t.Errorf("game from position = %+v", game)
+	}
+
+	// Restarting without options starts from the same position.
+	rec = do(s, http.MethodPost, "/games/"+game.ID+"/move", `{"move":"`+game.LegalMoves[0]+`"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("move: %d %s", rec.Code, rec.Body)
+	}
+	rec = do(s, http.MethodPost, "/games/"+game.ID+"/new", "")
+	if resp := decode(t, rec); resp.Black != game.Black || resp.White != game.White || resp.BlackTurn {
+		t.Errorf("restarted game = %+v", resp)
+	}
+}
+
+func TestUnknownGame(t *testing.T) {
+	s := newTestServer(t)
+
+	if rec := do(s, http.MethodGet, "/games/nope/state", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("GET unknown state: %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if rec := do(s, http.MethodPost, "/games/nope/move", `{"move":"c5"}`); rec.Code != http.StatusNotFound {
+		t.Errorf("POST unknown move: %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestMoveDuringBotTurnIsRejected(t *testing.T) {
+	s := newTestServer(t)
+	gate := make(testbot.Gate)
+	s.Store.Add(NewSession("gate", "black", gate, board.NewBoard()))
+
+	if rec := do(s, http.MethodPost, "/games/gate/move", `{"move":"c5"}`); rec.Code != http.StatusOK {
+		t.Fatalf("first move: %d %s", rec.Code, rec.Body)
+	}
+
+	before := session(t, s, "gate").Board()
+	rec := do(s, http.MethodPost, "/games/gate/move", `{"move":"e3"}`)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("move on the bot's turn: %d %s, want %d", rec.Code, rec.Body, http.StatusConflict)
+	}
+	if e := decodeError(t, rec); e.Code != string(board.CodeNotYourTurn) || !strings.Contains(e.Message, "not your turn") {
+		t.Errorf("error = %+v", e)
+	}
+	if after := session(t, s, "gate").Board(); after != before {
+		t.Errorf("rejected move changed the board")
+	}
+
+	gate <- struct{}{}
+	waitTurn(t, s, "/games/gate/state", true)
+}
+
+func TestConcurrentRequests(t *testing.T) {
+	s := newTestServer(t)
+	race := NewSession("race", "black", board.RandomPlayer{}, board.NewBoard())
+	s.Store.Add(race)
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 200 {
+				rec := do(s, http.MethodGet, "/games/race/state", "")
+				if rec.Code != http.StatusOK {
+					t.Errorf("state: %d", rec.Code)
+					return
+				}
+
+				b := race.Board()
+				moves := b.LegalMoves()
+				if !b.BlackTurn || moves == 0 {
+					continue
+				}
+				x, y := board.BitToSquare(moves & -moves)
+				body := `{"move":"` + board.SquareName(x, y) + `"}`
+
+				rec = do(s, http.MethodPost, "/games/race/move", body)
+				switch rec.Code {
+				case http.StatusOK, http.StatusUnprocessableEntity, http.StatusConflict:
+				default:
+					t.Errorf("move: %d %s", rec.Code, rec.Body)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestLegacyEndpointsUseDefaultSession(t *testing.T) {
+	s := newTestServer(t)
+
+	resp := decode(t, do(s, http.MethodGet, "/state", ""))
+	if resp.ID != DefaultID {
+		t.Fatalf("GET /state id = %q, want %q", resp.ID, DefaultID)
+	}
+
+	if rec := do(s, http.MethodPost, "/move", `{"move":"c5"}`); rec.Code != http.StatusOK {
+		t.Fatalf("POST /move: %d %s", rec.Code, rec.Body)
+	}
+	waitTurn(t, s, "/state", true)
+	waitTurn(t, s, "/games/"+DefaultID+"/state", true)
+}
+
+func TestStoreExpire(t *testing.T) {
+	st := NewStore(time.Minute)
+	st.Add(NewSession("old", "black", board.RandomPlayer{}, board.NewBoard()))
+
+	if n := st.Expire(time.Now()); n != 0 {
+		t.Errorf("expired %d fresh sessions", n)
+	}
+	if n := st.Expire(time.Now().Add(time.Hour)); n != 1 {
+		t.Errorf("expired %d idle sessions, want 1", n)
+	}
+	if _, ok := st.Get("old"); ok {
+		t.Errorf("expired session still there")
+	}
+}
+
+func nextEvent(t *testing.T, events <-chan Event) Event {
+	t.Helper()
+	select {
+	case ev := <-events:
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event")
+		return Event{}
+	}
+}
+
+func TestSessionEvents(t *testing.T) {
+	gate := make(testbot.Gate)
+	session := NewSession("events", "black", gate, board.NewBoard())
+	events, stop := session.Subscribe()
+	defer stop()
+
+	if err := session.Move("c5"); err != nil {
+		t.Fatal(err)
+	}
+
+	ev := nextEvent(t, events)
+	if ev.Type != "move" || ev.Side != "black" || ev.Move != "c5" {
+		t.Fatalf("first event = %+v", ev)
+	}
+	if len(ev.Flipped) != 1 || ev.Flipped[0] != "d5" {
+		t.Errorf("flipped = %v, want [d5]", ev.Flipped)
+	}
+	if ev.State.BlackTurn {
+		t.Errorf("state after black's move says black to move")
+	}
+
+	if ev := nextEvent(t, events); ev.Type != "thinking" || ev.Side != "white" {
+		t.Fatalf("second event = %+v", ev)
+	}
+
+	gate <- struct{}{}
+	if ev := nextEvent(t, events); ev.Type != "move" || ev.Side != "white" || !ev.State.BlackTurn {
+		t.Fatalf("third event = %+v", ev)
+	}
+}
+
+func TestSlowSubscriberIsCutOff(t *testing.T) {
+	session := NewSession("slow", "black", make(testbot.Gate), board.NewBoard())
+	events, stop := session.Subscribe()
+	defer stop()
+
+	session.mu.Lock()
+	for i := 0; i < 65; i++ {
+		session.publish(Event{Type: "state"})
+	}
+	session.mu.Unlock()
+
+	n := 0
+	for range events {
+		n++
+	}
+	if n != 64 {
+		t.Fatalf("got %d events before the channel closed, want 64", n)
+	}
+}
+
+func TestGameOverKeepsZeroScore(t *testing.T) {
+	data, err := json.Marshal(Event{Type: "gameover", BlackDiscs: 64, Winner: "black"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"white_discs":0`) {
+		t.Fatalf("gameover event %s has no white_discs", data)
+	}
+}
+
+func TestStateDescribesLastMove(t *testing.T) {
+	s := newTestServer(t)
+	s.Store.Add(NewSession("state", "black", make(testbot.Gate), board.NewBoard()))
+
+	start := decode(t, do(s, http.MethodGet, "/games/state/state", ""))
+	if len(start.LegalMoves) != 4 || start.BlackDiscs != 2 || start.WhiteDiscs != 2 || start.GameOver {
+		t.Fatalf("start state = %+v", start)
+	}
+
+	resp := decode(t, do(s, http.MethodPost, "/games/state/move", `{"move":"c5"}`))
+	if resp.LastMove != "c5" || len(resp.Flipped) != 1 || resp.Flipped[0] != "d5" {
+		t.Errorf("last move = %q flipped %v, want c5 [d5]", resp.LastMove, resp.Flipped)
+	}
+	if resp.BlackDiscs != 4 || resp.WhiteDiscs != 1 {
+		t.Errorf("discs = %d-%d, want 4-1", resp.BlackDiscs, resp.WhiteDiscs)
+	}
+	if resp.Black == "" || resp.White == "" {
+		t.Errorf("bitboards missing for old clients")
+	}
+}
+
+func TestMoveAfterGameOver(t *testing.T) {
+	s := newTestServer(t)
+	over := board.Board{Black: board.BlackStart | board.WhiteStart, BlackTurn: true}
+	s.Store.Add(NewSession("over", "black", board.RandomPlayer{}, over))
+
+	resp := decode(t, do(s, http.MethodGet, "/games/over/state", ""))
+	if !resp.GameOver || resp.Winner != "black" || len(resp.LegalMoves) != 0 {
+		t.Errorf("finished state = %+v", resp)
+	}
+
+	rec := do(s, http.MethodPost, "/games/over/move", `{"move":"c5"}`)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("move after the end: %d %s", rec.Code, rec.Body)
+	}
+	if e := decodeError(t, rec); e.Code != string(board.CodeGameOver) || !strings.Contains(e.Message, "game over") {
+		t.Errorf("error = %+v", e)
+	}
+}
+
+func TestMoveErrorCodes(t *testing.T) {
+	s := newTestServer(t)
+
+	for _, tc := range []struct {
+		body   string
+		status int
+		code   string
+	}{
+		{`{"move":"z9"}`, http.StatusBadRequest, string(board.CodeInvalidFormat)},
+		{`{"move":"d4"}`, http.StatusUnprocessableEntity, string(board.CodeOccupied)},
+		{`{"move":"a1"}`, http.StatusUnprocessableEntity, string(board.CodeNoFlips)},
+		{`{"move":`, http.StatusBadRequest, CodeInvalidRequest},
+	} {
+		s.Store.Add(NewSession("codes", "black", make(testbot.Gate), board.NewBoard()))
+		rec := do(s, http.MethodPost, "/games/codes/move", tc.body)
+		if rec.Code != tc.status {
+			t.Errorf("move %s: %d, want %d", tc.body, rec.Code, tc.status)
+			continue
+		}
+		if e := decodeError(t, rec); e.Code != tc.code || e.Message == "" {
+			t.Errorf("move %s: error = %+v, want code %q", tc.body, e, tc.code)
+		}
+	}
+
+	rec := do(s, http.MethodGet, "/games/nope/state", "")
+	if e := decodeError(t, rec); e.Code != CodeUnknownGame {
+		t.Errorf("unknown game code = %q", e.Code)
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	s := newTestServer(t)
+
+	for _, tc := range []struct{ method, path, allow string }{
+		{http.MethodGet, "/move", http.MethodPost},
+		{http.MethodPost, "/state", http.MethodGet},
+		{http.MethodGet, "/games", http.MethodPost},
+		{http.MethodDelete, "/games/default/move", http.MethodPost},
+		{http.MethodPut, "/games/default/state", http.MethodGet},
+	} {
+		rec := do(s, tc.method, tc.path, "")
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("%s %s: %d, want %d", tc.method, tc.path, rec.Code, http.StatusMethodNotAllowed)
+			continue
+		}
+		if got := rec.Header().Get("Allow"); got != tc.allow {
+			t.Errorf("%s %s: Allow = %q, want %q", tc.method, tc.path, got, tc.allow)
+		}
+		if e := decodeError(t, rec); e.Code != CodeMethodNotAllowed {
+			t.Errorf("%s %s: code = %q", tc.method, tc.path, e.Code)
+		}
+	}
+
+	if rec := do(s, http.MethodHead, "/state", ""); rec.Code != http.StatusOK {
+		t.Errorf("HEAD /state: %d", rec.Code)
+	}
+}
+
+func TestGamesSurviveRestart(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestServer(t)
+	s.DataDir = dir
+
+	game := decode(t, do(s, http.MethodPost, "/games", `{"opponent":"random","opening":"random"}`))
+	if rec := do(s, http.MethodPost, "/games/"+game.ID+"/move", `{"move":"`+game.LegalMoves[0]+`"}`); rec.Code != http.StatusOK {
+		t.Fatalf("move: %d %s", rec.Code, rec.Body)
+	}
+	before := waitTurn(t, s, "/games/"+game.ID+"/state", true)
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if rec := do(s, http.MethodPost, "/games/"+game.ID+"/move", `{"move":"`+before.LegalMoves[0]+`"}`); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("move after Close: %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	restarted := newTestServer(t)
+	restarted.DataDir = dir
+	if n, err := restarted.Load(); n != 1 || err != nil {
+		t.Fatalf("Load = %d, %v", n, err)
+	}
+	after := decode(t, do(restarted, http.MethodGet, "/games/"+game.ID+"/state", ""))
+	if after.Black != before.Black || after.White != before.White || after.LastMove != before.LastMove {
+		t.Errorf("restored %+v, want %+v", after, before)
+	}
+	if got := session(t, restarted, game.ID).Options.Opponent; got != "random" {
+		t.Errorf("restored opponent = %q", got)
+	}
+}
+
+func TestRestoredBotPlaysOn(t *testing.T) {
+	var played [2]BoardResponse
+	for i := range played {
+		dir := t.TempDir()
+		s := newTestServer(t)
+		s.DataDir = dir
+		game := decode(t, do(s, http.MethodPost, "/games", `{"level":1,"seed":7}`))
+		path := "/games/" + game.ID
+
+		for move := 0; move < 8; move++ {
+			if i == 1 && move == 4 {
+				// Restart halfway through the second game.
+				if err := s.Close(); err != nil {
+					t.Fatal(err)
+				}
+				s = newTestServer(t)
+				s.DataDir = dir
+				if n, err := s.Load(); n != 1 || err != nil {
+					t.Fatalf("Load = %d, %v", n, err)
+				}
+			}
+			state := waitTurn(t, s, path+"/state", true)
+			if rec := do(s, http.MethodPost, path+"/move", `{"move":"`+state.LegalMoves[0]+`"}`); rec.Code != http.StatusOK {
+				t.Fatalf("move: %d %s", rec.Code, rec.Body)
+			}
+		}
+		played[i] = waitTurn(t, s, path+"/state", true)
+		played[i].ID = ""
+	}
+	if played[0].Black != played[1].Black || played[0].White != played[1].White {
+		t.Errorf("restored game went %+v, not %+v", played[1], played[0])
+	}
+}
+
+func TestLoadTrimsBrokenLog(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestServer(t)
+	s.DataDir = dir
+
+	game := decode(t, do(s, http.MethodPost, "/games", `{"opponent":"random"}`))
+	do(s, http.MethodPost, "/games/"+game.ID+"/move", `{"move":"c5"}`)
+	waitTurn(t, s, "/games/"+game.ID+"/state", true)
+	s.Close()
+
+	path := logPath(dir, game.ID)
+	good, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, append(good, `{"type":"mo`...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted := newTestServer(t)
+	restarted.DataDir = dir
+	if n, err := restarted.Load(); n != 1 || err != nil {
+		t.Fatalf("Load = %d, %v", n, err)
+	}
+	if resp := decode(t, do(restarted, http.MethodGet, "/games/"+game.ID+"/state", "")); discs(t, resp) != 6 {
+		t.Errorf("restored %d discs, want 6", discs(t, resp))
+	}
+	if got, _ := os.ReadFile(path); string(got) != string(good) {
+		t.Errorf("broken entry left in the log:\n%s", got)
+	}
+}
+
+func TestExpireKeepsLog(t *testing.T) {
+	s := newTestServer(t)
+	s.DataDir = t.TempDir()
+
+	game := decode(t, do(s, http.MethodPost, "/games", `{"opponent":"random"}`))
+	if _, err := os.Stat(logPath(s.DataDir, game.ID)); err != nil {
+		t.Fatal(err)
+	}
+	s.Store.Expire(time.Now().Add(time.Hour))
+	if _, err := os.Stat(logPath(s.DataDir, game.ID)); !os.IsNotExist(err) {
+		t.Errorf("log of an expired game left to be loaded: %v", err)
+	}
+	if _, err := os.Stat(logPath(filepath.Join(s.DataDir, "expired"), game.ID)); err != nil {
+		t.Errorf("log of an expired game not kept: %v", err)
+	}
+
+	restarted := newTestServer(t)
+	restarted.DataDir = s.DataDir
+	if n, err := restarted.Load(); n != 0 || err != nil {
+		t.Errorf("Load = %d, %v, want no games", n, err)
+	}
+}
+
+func TestWatchedSessionDoesNotExpire(t *testing.T) {
+	st := NewStore(time.Minute)
+	session := NewSession("watched", "black", board.RandomPlayer{}, board.NewBoard())
+	st.Add(session)
+	events, stop := session.Subscribe()
+
+	if n := st.Expire(time.Now().Add(time.Hour)); n != 0 {
+		t.Errorf("expired %d watched sessions", n)
+	}
+	stop()
+	if n := st.Expire(time.Now().Add(time.Hour)); n != 1 {
+		t.Errorf("expired %d idle sessions, want 1", n)
+	}
+
+	// Closing the session ends its event streams.
+	session = NewSession("closed", "black", board.RandomPlayer{}, board.NewBoard())
+	events, stop = session.Subscribe()
+	defer stop()
+	session.Close()
+	if _, ok := <-events; ok {
+		t.Error("event stream open after Close")
+	}
+}
+
+func TestEventsKeepSessionAlive(t *testing.T) {
+	st := NewStore(time.Minute)
+	session := NewSession("busy", "black", board.RandomPlayer{}, board.NewBoard())
+	st.Add(session)
+	session.lastSeen.Store(time.Now().Add(-time.Hour).UnixNano())
+
+	session.mu.Lock()
+	session.publish(Event{Type: "state"})
+	session.mu.Unlock()
+	if n := st.Expire(time.Now()); n != 0 {
+		t.Errorf("expired %d sessions with recent events", n)
+	}
+}
+
+func TestUndoStopsTheBot(t *testing.T) {
+	s := newTestServer(t)
+	gate := make(testbot.Gate)
+	s.Store.Add(NewSession("undo", "black", gate, board.NewBoard()))
+
+	if rec := do(s, http.MethodPost, "/games/undo/move", `{"move":"c5"}`); rec.Code != http.StatusOK {
+		t.Fatalf("move: %d %s", rec.Code, rec.Body)
+	}
+	rec := do(s, http.MethodPost, "/games/undo/undo", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("undo while the bot thinks: %d %s", rec.Code, rec.Body)
+	}
+	if resp := decode(t, rec); !resp.BlackTurn || discs(t, resp) != 4 || resp.LastMove != "" {
+		t.Errorf("after undo = %+v", resp)
+	}
+
+	if rec := do(s, http.MethodPost, "/games/undo/move", `{"move":"e3"}`); rec.Code != http.StatusOK {
+		t.Fatalf("move after undo: %d %s", rec.Code, rec.Body)
+	}
+	// The cancelled search and the new one each take a turn at the gate.
+	gate <- struct{}{}
+	gate <- struct{}{}
+	if resp := waitTurn(t, s, "/games/undo/state", true); discs(t, resp) != 6 {
+		t.Errorf("discs = %d, want 6: the cancelled search played too", discs(t, resp))
+	}
+}
+
+func TestUndoTakesBackTheBotReply(t *testing.T) {
+	s := newTestServer(t)
+	game := decode(t, do(s, http.MethodPost, "/games", `{"opponent":"random"}`))
+	path := "/games/" + game.ID
+
+	if rec := do(s, http.MethodPost, path+"/undo", ""); rec.Code != http.StatusConflict {
+		t.Errorf("undo before moving: %d, want %d", rec.Code, http.StatusConflict)
+	} else if e := decodeError(t, rec); e.Code != CodeNothingToUndo {
+		t.Errorf("undo before moving: code %q", e.Code)
+	}
+
+	do(s, http.MethodPost, path+"/move", `{"move":"c5"}`)
+	waitTurn(t, s, path+"/state", true)
+
+	rec := do(s, http.MethodPost, path+"/undo", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("undo: %d %s", rec.Code, rec.Body)
+	}
+	if resp := decode(t, rec); !resp.BlackTurn || discs(t, resp) != 4 {
+		t.Errorf("after undo = %+v", resp)
+	}
+}
+
+func TestResign(t *testing.T) {
+	s := newTestServer(t)
+	s.Store.Add(NewSession("resign", "black", make(testbot.Gate), board.NewBoard()))
+
+	rec := do(s, http.MethodPost, "/games/resign/resign", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("resign: %d %s", rec.Code, rec.Body)
+	}
+	if resp := decode(t, rec); !resp.GameOver || resp.Resigned != "black" || resp.Winner != "white" {
+		t.Errorf("after resigning = %+v", resp)
+	}
+
+	for _, path := range []string{"/games/resign/resign", "/games/resign/move", "/games/resign/undo"} {
+		rec := do(s, http.MethodPost, path, `{"move":"c5"}`)
+		if rec.Code != http.StatusConflict {
+			t.Errorf("POST %s after resigning: %d, want %d", path, rec.Code, http.StatusConflict)
+		}
+	}
+}
+
+func TestNewGameInPlace(t *testing.T) {
+	s := newTestServer(t)
+	game := decode(t, do(s, http.MethodPost, "/games", `{"opponent":"random"}`))
+	path := "/games/" + game.ID
+	events, stop := session(t, s, game.ID).Subscribe()
+	defer stop()
+
+	do(s, http.MethodPost, path+"/resign", "")
+	if rec := do(s, http.MethodPost, path+"/new", ""); rec.Code != http.StatusOK {
+		t.Fatalf("new: %d %s", rec.Code, rec.Body)
+	} else if resp := decode(t, rec); resp.GameOver || discs(t, resp) != 4 {
+		t.Errorf("new game = %+v", resp)
+	}
+
+	if rec := do(s, http.MethodPost, path+"/new", `{"color":"white","opponent":"greedy","depth":1}`); rec.Code != http.StatusOK {
+		t.Fatalf("new as white: %d %s", rec.Code, rec.Body)
+	}
+	waitTurn(t, s, path+"/state", false)
+	if got := session(t, s, game.ID).options(); got.Color != "white" || got.Opponent != "greedy" {
+		t.Errorf("options = %+v", got)
+	}
+
+	if rec := do(s, http.MethodPost, path+"/new", `{"color":"red"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("new with a bad option: %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	for _, want := range []string{"gameover", "new", "new", "thinking", "move"} {
+		ev := nextEvent(t, events)
+		for ev.Type == "progress" {
+			ev = nextEvent(t, events)
+		}
+		if ev.Type != want {
+			t.Fatalf("event %+v, want %q", ev, want)
+		}
+	}
+}
+
+func TestRestoreReplaysUndoNewAndResign(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestServer(t)
+	s.DataDir = dir
+
+	game := decode(t, do(s, http.MethodPost, "/games", `{"opponent":"random"}`))
+	path := "/games/" + game.ID
+	do(s, http.MethodPost, path+"/move", `{"move":"c5"}`)
+	waitTurn(t, s, path+"/state", true)
+	do(s, http.MethodPost, path+"/undo", "")
+	do(s, http.MethodPost, path+"/new", `{"opponent":"random","opening":"random"}`)
+	do(s, http.MethodPost, path+"/resign", "")
+	want := decode(t, do(s, http.MethodGet, path+"/state", ""))
+	s.Close()
+
+	restarted := newTestServer(t)
+	restarted.DataDir = dir
+	if n, err := restarted.Load(); n != 1 || err != nil {
+		t.Fatalf("Load = %d, %v", n, err)
+	}
+	got := decode(t, do(restarted, http.MethodGet, path+"/state", ""))
+	if got.Black != want.Black || got.White != want.White || got.Resigned != "black" || !got.GameOver {
+		t.Errorf("restored %+v, want %+v", got, want)
+	}
+}
+
+func TestHumanLosesOnTime(t *testing.T) {
+	s := newTestServer(t)
+	game := decode(t, do(s, http.MethodPost, "/games", `{"opponent":"random","time_control":"30ms"}`))
+	path := "/games/" + game.ID
+	if game.Clocks == nil || game.Clocks.Running != "black" || game.Clocks.Black > 30 {
+		t.Fatalf("clocks of a new game = %+v", game.Clocks)
+	}
+
+	var resp BoardResponse
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if resp = decode(t, do(s, http.MethodGet, path+"/state", "")); resp.GameOver {
+			break
+		}
+	}
+	if !resp.GameOver || resp.Flagged != "black" || resp.Winner != "white" || resp.Clocks.Black != 0 {
+		t.Fatalf("after black's time ran out: %+v", resp)
+	}
+
+	rec := do(s, http.MethodPost, path+"/move", `{"move":"c5"}`)
+	if e := decodeError(t, rec); rec.Code != http.StatusConflict || e.Code != string(board.CodeGameOver) {
+		t.Errorf("move after losing on time: %d %+v", rec.Code, e)
+	}
+}
+
+func TestLateMoveLosesOnTime(t *testing.T) {
+	s := newTestServer(t)
+	game := decode(t, do(s, http.MethodPost, "/games", `{"opponent":"random","time_control":"1h"}`))
+	session := session(t, s, game.ID)
+
+	// Make the move late without waiting an hour for the flag to fall.
+	session.mu.Lock()
+	session.turnStart = session.turnStart.Add(-2 * time.Hour)
+	session.mu.Unlock()
+
+	rec := do(s, http.MethodPost, "/games/"+game.ID+"/move", `{"move":"c5"}`)
+	if e := decodeError(t, rec); rec.Code != http.StatusConflict || e.Code != string(board.CodeOutOfTime) {
+		t.Errorf("late move: %d %+v", rec.Code, e)
+	}
+	if resp := session.State(); resp.Flagged != "black" || discs(t, resp) != 4 {
+		t.Errorf("after a late move: %+v", resp)
+	}
+}
+
+func TestClocksSurviveRestart(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestServer(t)
+	s.DataDir = dir
+
+	game := decode(t, do(s, http.MethodPost, "/games", `{"opponent":"random","time_control":"1m+1s"}`))
+	path := "/games/" + game.ID
+	do(s, http.MethodPost, path+"/move", `{"move":"c5"}`)
+	before := waitTurn(t, s, path+"/state", true)
+	s.Close()
+	if c := before.Clocks; c.White <= 60000 || c.Running != "black" {
+		t.Errorf("clocks after a move each = %+v, want the increment added", c)
+	}
+
+	restarted := newTestServer(t)
+	restarted.DataDir = dir
+	if n, err := restarted.Load(); n != 1 || err != nil {
+		t.Fatalf("Load = %d, %v", n, err)
+	}
+	after := decode(t, do(restarted, http.MethodGet, path+"/state", ""))
+	if after.Clocks == nil || after.Clocks.White != before.Clocks.White || after.Clocks.Black > before.Clocks.Black {
+		t.Errorf("restored clocks %+v, want %+v", after.Clocks, before.Clocks)
+	}
+}
+
+func decodeAnalysis(t *testing.T, rec *httptest.ResponseRecorder) AnalyzeResponse {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("analyze: %d %s", rec.Code, rec.Body)
+	}
+	var resp AnalyzeResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestAnalyzeStart(t *testing.T) {
+	s := newTestServer(t)
+
+	start := board.NewBoard()
+	for _, body := range []string{
+		`{"position":"` + board.Position(start) + `","depth":3}`,
+		`{"black":"` + strconv.FormatUint(start.Black, 10) + `","white":"` + strconv.FormatUint(start.White, 10) + `","black_turn":true,"depth":3}`,
+	} {
+		resp := decodeAnalysis(t, do(s, http.MethodPost, "/analyze", body))
+		if len(resp.Moves) != 4 || resp.Exact || resp.Depth != 3 {
+			t.Errorf("analysis = %+v", resp)
+		}
+		// The opening moves are symmetric, so they all score the same.
+		for _, m := range resp.Moves {
+			if m.Score != resp.Moves[0].Score {
+				t.Errorf("%s scores %d, %s %d", m.Move, m.Score, resp.Moves[0].Move, resp.Moves[0].Score)
+			}
+		}
+		if len(resp.PV) != 3 || resp.PV[0] != resp.Moves[0].Move {
+			t.Errorf("pv = %v, best move %s", resp.PV, resp.Moves[0].Move)
+		}
+	}
+}
+
+func TestAnalyzeSolvesEndgame(t *testing.T) {
+	s := newTestServer(t)
+
+	// Black takes h8 and wins 63-0 with one square left, which white can't
+	// use either, so it goes to black.
+	position := strings.Repeat("*", 62) + "O-" + " *"
+	resp := decodeAnalysis(t, do(s, http.MethodPost, "/analyze", `{"position":"`+position+`"}`))
+	if !resp.Exact || len(resp.Moves) != 1 || resp.Moves[0].Move != "h8" || resp.Moves[0].Score != 64 {
+		t.Errorf("analysis = %+v", resp)
+	}
+	if len(resp.PV) != 1 || resp.PV[0] != "h8" {
+		t.Errorf("pv = %v", resp.PV)
+	}
+}
+
+func TestAnalyzeRejectsBadRequests(t *testing.T) {
+	s := newTestServer(t)
+
+	for _, body := range []string{
+		`not json`,
+		`{"position":"xyz"}`,
+		`{"black":"3","white":"1"}`,
+		`{"position":"` + board.Position(board.NewBoard()) + `","depth":99}`,
+	} {
+		if rec := do(s, http.MethodPost, "/analyze", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("analyze %s: %d, want %d", body, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
@@ -0,0 +1,463 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"Othello-Engine/board"
+)
+
+var (
+	// ErrNotYourTurn rejects a human move made while the bot is to move.
+	ErrNotYourTurn = &board.InvalidMoveError{Code: board.CodeNotYourTurn, Reason: "not your turn, the bot is thinking"}
+	// ErrGameOver rejects a move after the game has ended.
+	ErrGameOver = &board.InvalidMoveError{Code: board.CodeGameOver, Reason: "game over"}
+	// ErrClosed rejects moves once the server is shutting down.
+	ErrClosed = errors.New("game closed")
+	// ErrNothingToUndo rejects an undo before the human has moved.
+	ErrNothingToUndo = errors.New("nothing to undo")
+	// ErrOutOfTime rejects a move made after the player's clock ran out,
+	// losing the game.
+	ErrOutOfTime = &board.InvalidMoveError{Code: board.CodeOutOfTime, Reason: "out of time"}
+)
+
+// position is a point in the game that undo can go back to.
+type position struct {
+	game        board.Board
+	lastMove    string
+	lastFlipped uint64
+	passed      string
+}
+
+// Session is one game between a human and a bot. The game is guarded by
+// mu; the bot searches on a copy and only takes the lock to play its move.
+type Session struct {
+	ID      string
+	Human   string // side the human plays, "black" or "white"
+	Bot     board.Player
+	Options NewGameRequest // how the game was made, for restoring it
+
+	mu sync.Mutex
+	position
+	history     []position // before each move played
+	resigned    string     // side that resigned, if any
+	clocks      [2]board.Clock
+	turnStart   time.Time   // when the clock of the side to move started; zero while stopped
+	timer       *time.Timer // flags the side to move when its time is up
+	flagged     string      // side that ran out of time, if any
+	thinking    bool
+	cancel      context.CancelFunc // stops the bot's search
+	closed      bool
+	log         *os.File
+	subscribers map[chan Event]struct{}
+	source      *lockedSource // the bot's random choices; nil for a bot given no seed
+
+	lastSeen atomic.Int64 // UnixNano of the last request for the game or event in it
+}
+
+func NewSession(id, human string, bot board.Player, start board.Board) *Session {
+	s := &Session{
+		ID:          id,
+		Human:       human,
+		Bot:         bot,
+		position:    position{game: start},
+		subscribers: make(map[chan Event]struct{}),
+	}
+	s.touch()
+	return s
+}
+
+// touch marks the session as in use, keeping it from expiring.
+func (s *Session) touch() {
+	s.lastSeen.Store(time.Now().UnixNano())
+}
+
+// options returns how the current game was made.
+func (s *Session) options() NewGameRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Options
+}
+
+// Board returns a copy of the current position.
+func (s *Session) Board() board.Board {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.game
+}
+
+// State returns the current position with everything a client shows.
+func (s *Session) State() BoardResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state()
+}
+
+// state is State for callers holding s.mu.
+func (s *Session) state() BoardResponse {
+	b := s.game
+	resp := BoardResponse{
+		ID:         s.ID,
+		Black:      fmt.Sprintf("%d", b.Black),
+		White:      fmt.Sprintf("%d", b.White),
+		BlackTurn:  b.BlackTurn,
+		LegalMoves: squareNames(b.LegalMoves()),
+		LastMove:   s.lastMove,
+		Flipped:    squareNames(s.lastFlipped),
+		Passed:     s.passed,
+		Resigned:   s.resigned,
+		Flagged:    s.flagged,
+		Clocks:     s.clocksState(),
+		GameOver:   s.over(),
+		Seed:       s.Options.Seed,
+	}
+	resp.BlackDiscs, resp.WhiteDiscs = b.Count()
+	if resp.GameOver {
+		resp.Winner = s.winner()
+	}
+	return resp
+}
+
+func (s *Session) humanTurn() bool {
+	return s.game.BlackTurn == (s.Human == "black")
+}
+
+// over reports whether the game has ended, by resignation, on time or
+// otherwise.
+func (s *Session) over() bool {
+	return s.resigned != "" || s.flagged != "" || s.game.GameOver()
+}
+
+func (s *Session) winner() string {
+	if s.resigned != "" {
+		return sideName(s.resigned != "black")
+	}
+	if s.flagged != "" {
+		return sideName(s.flagged != "black")
+	}
+	return winner(s.game)
+}
+
+// Move plays the human's move and lets the bot reply. It fails while the
+// bot is to move.
+func (s *Session) Move(move string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+	if s.over() {
+		return ErrGameOver
+	}
+	if !s.humanTurn() {
+		return ErrNotYourTurn
+	}
+	x, y, err := board.ParseSquare(move)
+	if err != nil {
+		return err
+	}
+	if err := s.play(x, y); err != nil {
+		return err
+	}
+	s.startBot()
+	return nil
+}
+
+// Undo takes back the human's last move and the bot's replies to it,
+// stopping the bot if it is still thinking.
+func (s *Session) Undo() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+	if s.resigned != "" || s.flagged != "" {
+		return ErrGameOver
+	}
+	return s.undo()
+}
+
+// undo goes back to the last position with the human to move, whose
+// clock starts again with the time it had left. s.mu must be held.
+func (s *Session) undo() error {
+	human := s.Human == "black"
+	for i := len(s.history) - 1; i >= 0; i-- {
+		if s.history[i].game.BlackTurn != human {
+			continue
+		}
+
+		s.stopBot()
+		s.position = s.history[i]
+		s.history = s.history[:i]
+		if s.running() {
+			s.startClock()
+		}
+		s.record(Entry{Type: "undo"})
+		s.publish(Event{Type: "undo", Side: s.Human})
+		return nil
+	}
+	return ErrNothingToUndo
+}
+
+// Resign ends the game as a loss for the human.
+func (s *Session) Resign() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+	if s.over() {
+		return ErrGameOver
+	}
+	s.resign()
+	return nil
+}
+
+// resign ends the game as a loss for the human. s.mu must be held.
+func (s *Session) resign() {
+	s.stopBot()
+	s.stopTimer()
+	s.turnStart = time.Time{}
+	s.resigned = s.Human
+	s.record(Entry{Type: "resign"})
+
+	black, white := s.game.Count()
+	s.publish(Event{Type: "gameover", Side: s.Human, BlackDiscs: black, WhiteDiscs: white, Winner: s.winner()})
+}
+
+// Reset starts a new game in the session, keeping its ID and subscribers.
+// src is where bot draws its random choices, as players returns it.
+func (s *Session) Reset(human string, bot board.Player, src *lockedSource, opts NewGameRequest, start board.Board) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+	s.reset(human, bot, src, opts, start)
+	s.startClock()
+	s.record(Entry{Type: "new", Game: &opts, Start: board.GGF(start)})
+	s.publish(Event{Type: "new"})
+	s.startBot()
+	return nil
+}
+
+// reset clears the game for a new one. s.mu must be held.
+func (s *Session) reset(human string, bot board.Player, src *lockedSource, opts NewGameRequest, start board.Board) {
+	s.stopBot()
+	s.Human = human
+	s.Bot = bot
+	s.source = src
+	s.Options = opts
+	s.position = position{game: start}
+	s.history = nil
+	s.resigned = ""
+	s.resetClocks()
+}
+
+// Start starts the clocks, if they are not running yet, and lets the bot
+// move if it is its turn, as when the human plays white.
+func (s *Session) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.running() {
+		s.startClock()
+	}
+	s.startBot()
+}
+
+// startBot plays the bot's moves in the background until it is the
+// human's turn again. s.mu must be held.
+func (s *Session) startBot() {
+	if s.closed || s.thinking || s.over() || s.humanTurn() {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.thinking = true
+	s.cancel = cancel
+	s.publish(Event{Type: "thinking", Side: sideName(s.game.BlackTurn)})
+	go s.runBot(ctx, s.Bot, s.game, s.clock())
+}
+
+// runBot plays until the human is to move. Once ctx is cancelled the
+// search's result is dropped; whoever cancelled it has reset s.thinking,
+// as flag does when the bot's time runs out.
+func (s *Session) runBot(ctx context.Context, bot board.Player, b board.Board, clock board.Clock) {
+	for {
+		x, y, ok := board.GetMoveTimed(ctx, bot, &b, clock)
+
+		s.mu.Lock()
+		if ctx.Err() != nil {
+			s.mu.Unlock()
+			return
+		}
+		if ok {
+			ok = s.play(x, y) == nil
+		}
+		if !ok || s.game.GameOver() || s.humanTurn() {
+			s.stopBot()
+			s.mu.Unlock()
+			return
+		}
+		b, clock = s.game, s.clock()
+		s.mu.Unlock()
+	}
+}
+
+// stopBot cancels the bot's search, if any. s.mu must be held.
+func (s *Session) stopBot() {
+	if s.cancel != nil {
+		s.cancel()
+		s.cancel = nil
+	}
+	s.thinking = false
+}
+
+// Close stops the bot, ends the event streams and closes the game's log.
+// The session takes no more moves.
+func (s *Session) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	s.stopBot()
+	s.stopTimer()
+	for ch := range s.subscribers {
+		s.unsubscribe(ch)
+	}
+	if s.log == nil {
+		return nil
+	}
+	err := s.log.Close()
+	s.log = nil
+	return err
+}
+
+// watched reports whether anyone is subscribed to the session's events.
+func (s *Session) watched() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.subscribers) > 0
+}
+
+// expire closes the session and moves its log into the "expired"
+// directory beside it, where Load does not look.
+func (s *Session) expire() {
+	s.mu.Lock()
+	var path string
+	if s.log != nil {
+		path = s.log.Name()
+	}
+	s.mu.Unlock()
+
+	s.Close()
+	if path == "" {
+		return
+	}
+	dir := filepath.Join(filepath.Dir(path), "expired")
+	err := os.MkdirAll(dir, 0o755)
+	if err == nil {
+		err = os.Rename(path, filepath.Join(dir, filepath.Base(path)))
+	}
+	if err != nil {
+		log.Printf("game %s: log not moved aside: %v", s.ID, err)
+	}
+}
+
+// Store holds the live sessions and drops those idle for longer than TTL.
+type Store struct {
+	TTL time.Duration
+
+	mu       sync.Mutex
+	sessions map[string]*Session
+}
+
+func NewStore(ttl time.Duration) *Store {
+	return &Store{TTL: ttl, sessions: make(map[string]*Session)}
+}
+
+func (st *Store) Add(s *Session) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.sessions[s.ID] = s
+}
+
+// GetOrCreate returns the session with the given ID, adding the one built
+// by create if there is none.
+func (st *Store) GetOrCreate(id string, create func() (*Session, error)) (*Session, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if s, ok := st.sessions[id]; ok {
+		s.touch()
+		return s, nil
+	}
+
+	s, err := create()
+	if err != nil {
+		return nil, err
+	}
+	st.sessions[id] = s
+	return s, nil
+}
+
+// Get returns the session and marks it as used.
+func (st *Store) Get(id string) (*Session, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	s, ok := st.sessions[id]
+	if ok {
+		s.touch()
+	}
+	return s, ok
+}
+
+// Expire removes sessions that nobody is watching and that have seen no
+// request or event for longer than TTL. It closes them and moves their
+// logs aside, and returns how many it removed.
+func (st *Store) Expire(now time.Time) int {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	n := 0
+	for id, s := range st.sessions {
+		if now.Sub(time.Unix(0, s.lastSeen.Load())) > st.TTL && !s.watched() {
+			delete(st.sessions, id)
+			s.expire()
+			n++
+		}
+	}
+	return n
+}
+
+// Close closes every session, leaving their logs to be loaded again.
+func (st *Store) Close() error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	var errs []error
+	for _, s := range st.sessions {
+		errs = append(errs, s.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func newID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
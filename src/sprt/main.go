@@ -0,0 +1,97 @@
+// Sprt plays an engine configuration against a baseline until a sequential
+// probability ratio test accepts or rejects an Elo gain.
+//
+//	go run ./sprt -test nboard:8:./new-engine -base nboard:8:./old-engine -elo0 0 -elo1 10
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+
+	"Othello-Engine/board"
+	"Othello-Engine/match"
+)
+
+func main() {
+	testSpec := flag.String("test", "pengwin:4", "player under test")
+	baseSpec := flag.String("base", "greedy:4", "baseline player")
+	elo0 := flag.Float64("elo0", 0, "Elo gain under H0")
+	elo1 := flag.Float64("elo1", 10, "Elo gain under H1")
+	alpha := flag.Float64("alpha", 0.05, "false positive rate")
+	beta := flag.Float64("beta", 0.05, "false negative rate")
+	maxGames := flag.Int("games", 0, "stop after this many games (0: until decided)")
+	openings := flag.Int("openings", 100, "number of openings to cycle through")
+	plies := flag.Int("plies", 8, "opening length in plies")
+	verify := flag.Int("verify", 6, "search depth used to check openings are balanced")
+	margin := flag.Int("margin", 2, "largest opening score accepted as balanced")
+	xot := flag.String("xot", "", "read openings from an XOT list instead of generating them")
+	workers := flag.Int("workers", runtime.NumCPU(), "games played in parallel")
+	timeControl := flag.String("time", "", "time control of every game, BASE[+INC][/BYOYOMI] such as 1m+1s; empty for none")
+	seed := flag.Int64("seed", 0, "seed of the openings and the players' random choices; 0 picks one")
+	record := flag.String("record", "sprt.jsonl", "file to append game records to")
+	flag.Parse()
+
+	tc, err := board.ParseTimeControl(*timeControl)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	test, err := match.ParseEntrant(*testSpec)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	base, err := match.ParseEntrant(*baseSpec)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if *seed == 0 {
+		*seed = board.NewSeed()
+	}
+	fmt.Fprintf(os.Stderr, "seed %d\n", *seed)
+
+	starts, err := match.Openings(*xot, board.NewRand(*seed), *openings, *plies, *verify, *margin)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	out, err := os.OpenFile(*record, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer out.Close()
+
+	m := match.Match{
+		Test:     test,
+		Base:     base,
+		Openings: starts,
+		Workers:  *workers,
+		MaxGames: *maxGames,
+		Time:     tc,
+		Seed:     *seed,
+		Record:   out,
+		Progress: os.Stderr,
+	}
+	result, err := m.Run(match.SPRT{Elo0: *elo0, Elo1: *elo1, Alpha: *alpha, Beta: *beta})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	fmt.Println(result.String())
+	switch result.Status() {
+	case 1:
+		fmt.Printf("H1 accepted: %s gains at least %g Elo over %s\n", test.Name, *elo1, base.Name)
+	case -1:
+		fmt.Printf("H0 accepted: %s gains no more than %g Elo over %s\n", test.Name, *elo0, base.Name)
+	default:
+		fmt.Println("inconclusive")
+	}
+}
@@ -6,7 +6,6 @@ class Board {
       this.countBlack = 2;
       this.countWhite = 2;
       this.createBoard();
-      this.init();
     }
     
     createBoard() {
@@ -17,6 +16,10 @@ class Board {
         cell.textContent = '';
         
         cell.addEventListener('click', () => {
+            if (editor.active) {
+                editSquare(i);
+                return;
+            }
             handleMove(i);
             // console.log(i)
         });
@@ -55,39 +58,68 @@ class Board {
         }
     }      
 
-    async init() {
-        try {
-            const res = await fetch("/state");
-            const data = await res.json();
-            blackTurn = data.black_turn;
-
-            const blackBoard = BigInt(data.black);
-            const whiteBoard = BigInt(data.white);
+    render(state) {
+        lastState = state;
+        this.updateBoard(BigInt(state.black), BigInt(state.white));
+        count.textContent = `Black-${state.black_discs}   White-${state.white_discs}`;
 
-            this.updateBoard(blackBoard, whiteBoard);
-        } 
-        catch (err) {
-            console.error("Init error:", err);
+        for (const cell of this.cells) {
+            cell.classList.remove('hint', 'last', 'flipped');
         }
-      }
+        for (const square of state.legal_moves) {
+            this.cells[squareToIndex(square)].classList.add('hint');
+        }
+        if (state.last_move) {
+            this.cells[squareToIndex(state.last_move)].classList.add('last');
+        }
+        for (const square of state.flipped || []) {
+            this.cells[squareToIndex(square)].classList.add('flipped');
+        }
+    }
 }
 
 const board = new Board("board");
 const status = document.getElementById("status");
 const count = document.getElementById("count");
+const level = document.getElementById("level");
 
 let blackTurn = true;
+let lastState = null;
 
 function shiftChar(c, n) {
     return String.fromCharCode(c.charCodeAt(0) - n);
 }
 
+function squareToIndex(square) {
+    const x = 'h'.charCodeAt(0) - square.charCodeAt(0);
+    const y = Number(square[1]) - 1;
+    return y * 8 + x;
+}
+
 function indexToSquare(index) {
     let num = Math.floor((index / 8) + 1);
     let char = shiftChar('h', index % 8);
     return `${char}${num}`;
 }
 
+function turnText(blackTurn) {
+    return blackTurn ? "Black's turn (●)" : "White's turn (○)";
+}
+
+function resultText(state) {
+    const score = `${state.black_discs}-${state.white_discs}`;
+    if (state.resigned) {
+        return `Game over: ${sideText(state.resigned)} resigns, ${sideText(state.winner)} wins`;
+    }
+    return state.winner === "draw"
+        ? `Game over: draw ${score}`
+        : `Game over: ${sideText(state.winner)} wins ${score}`;
+}
+
+function sideText(side) {
+    return side === "black" ? "Black" : "White";
+}
+
 async function handleMove(index) {
     const move = indexToSquare(index);
     // console.log(move)
@@ -99,58 +131,221 @@ async function handleMove(index) {
         });
 
         if (!response.ok) {
+            const error = await response.json();
+            status.textContent = error.message;
             return;
         }
 
         const data = await response.json();
+        board.render(data);
+    } 
+    catch (err) {
+        console.error("Move error:", err);
+    }
+}
 
-        const blackBoard = BigInt(data.black);
-        const whiteBoard = BigInt(data.white);
+// sendCommand posts an undo, new game or resign request for the game shown.
+// A new game is against the level picked, if any.
+async function sendCommand(command) {
+    if (editor.active) {
+        stopEditing();
+    }
+    const request = { method: "POST" };
+    if (command === "new" && level.value) {
+        request.headers = { "Content-Type": "application/json" };
+        request.body = JSON.stringify({ level: Number(level.value) });
+    }
+    try {
+        const response = await fetch(`/games/default/${command}`, request);
+        const data = await response.json();
+        if (!response.ok) {
+            status.textContent = data.message;
+            return;
+        }
+        board.render(data);
+    }
+    catch (err) {
+        console.error(`${command} error:`, err);
+    }
+}
 
-        // console.log("Num:", blackBoard.toString(2));
-        board.updateBoard(blackBoard, whiteBoard);
-        
-        if (data.black_turn) {
-            status.textContent = "Black's turn (●)";
+for (const command of ["new", "undo", "resign"]) {
+    document.getElementById(command).addEventListener("click", () => sendCommand(command));
+}
+
+// The server pushes every change to the game, including the bot's moves.
+function handleEvent(event) {
+    if (editor.active) {
+        lastState = event.state;
+        return;
+    }
+    board.render(event.state);
+    blackTurn = event.state.black_turn;
+
+    switch (event.type) {
+        case "thinking":
+            status.textContent = `${sideText(event.side)} is thinking…`;
+            break;
+        case "progress":
+            status.textContent = `${sideText(event.side)} is thinking… ${event.done}/${event.total}`;
+            break;
+        case "pass":
+            status.textContent = `${sideText(event.side)} passes. ${turnText(blackTurn)}`;
+            break;
+        default:
+            status.textContent = event.state.game_over ? resultText(event.state) : turnText(blackTurn);
+    }
+}
+
+// The position editor sets up a board by hand: each click cycles a square
+// between empty, black and white. The game carries on underneath until the
+// edited position is played.
+const editor = {
+    active: false,
+    squares: [], // "", "black" or "white", by bit index
+    element: document.getElementById("editor"),
+    analysis: document.getElementById("analysis"),
+};
+
+function editorTurn() {
+    return document.querySelector('input[name="turn"]:checked').value;
+}
+
+function startEditing() {
+    const black = BigInt(lastState ? lastState.black : 0);
+    const white = BigInt(lastState ? lastState.white : 0);
+    for (let i = 0; i < 64; i++) {
+        if ((black >> BigInt(i)) & 1n) {
+            editor.squares[i] = "black";
+        } else if ((white >> BigInt(i)) & 1n) {
+            editor.squares[i] = "white";
         } else {
-            status.textContent = "White's turn (○)";
+            editor.squares[i] = "";
         }
-        count.textContent = `Black-${board.countBlack}   White-${board.countWhite}`
+    }
+    const turn = lastState && !lastState.black_turn ? "white" : "black";
+    document.querySelector(`input[name="turn"][value="${turn}"]`).checked = true;
 
-        if (!data.black_turn) {
-            pollForBotMove();
-        }
-    } 
-    catch (err) {
-        console.error("Move error:", err);
+    editor.active = true;
+    editor.element.hidden = false;
+    drawEditor();
+}
+
+function stopEditing() {
+    editor.active = false;
+    editor.element.hidden = true;
+    editor.analysis.textContent = "";
+    if (lastState) {
+        board.render(lastState);
+        status.textContent = lastState.game_over ? resultText(lastState) : turnText(lastState.black_turn);
     }
 }
 
-let polling = false;
+function editSquare(index) {
+    const next = { "": "black", "black": "white", "white": "" };
+    editor.squares[index] = next[editor.squares[index]];
+    editor.analysis.textContent = "";
+    drawEditor();
+}
 
-async function pollForBotMove() {
-    if (polling) return;
-    polling = true;
+function drawEditor() {
+    let black = 0n, white = 0n;
+    for (let i = 0; i < 64; i++) {
+        if (editor.squares[i] === "black") {
+            black |= 1n << BigInt(i);
+        } else if (editor.squares[i] === "white") {
+            white |= 1n << BigInt(i);
+        }
+    }
+    board.updateBoard(black, white);
+    for (const cell of board.cells) {
+        cell.classList.remove('hint', 'last', 'flipped');
+    }
+    count.textContent = `Black-${board.countBlack}   White-${board.countWhite}`;
+    status.textContent = "Editing: click a square to change it";
+}
 
-    const check = async () => {
-        const res = await fetch("/state");
-        const data = await res.json();
+// editorPosition writes the edited board like board.Position: squares a1
+// to h8, then the side to move.
+function editorPosition() {
+    const marks = { "": "-", "black": "*", "white": "O" };
+    let position = "";
+    for (let y = 0; y < 8; y++) {
+        for (let x = 7; x >= 0; x--) {
+            position += marks[editor.squares[y * 8 + x]];
+        }
+    }
+    return position + (editorTurn() === "black" ? " *" : " O");
+}
 
-        const blackBoard = BigInt(data.black);
-        const whiteBoard = BigInt(data.white);
+// playPosition restarts the game from the edited position, the human
+// playing the side to move.
+async function playPosition() {
+    try {
+        const response = await fetch("/games/default/new", {
+            method: "POST",
+            headers: { "Content-Type": "application/json" },
+            body: JSON.stringify({
+                position: editorPosition(),
+                color: editorTurn(),
+                level: Number(level.value),
+            }),
+        });
+        const data = await response.json();
+        if (!response.ok) {
+            status.textContent = data.message;
+            return;
+        }
+        lastState = data;
+        stopEditing();
+    }
+    catch (err) {
+        console.error("Play error:", err);
+    }
+}
 
-        board.updateBoard(blackBoard, whiteBoard);
-        count.textContent = `Black-${board.countBlack}   White-${board.countWhite}`;
+async function analysePosition() {
+    try {
+        const response = await fetch("/analyze", {
+            method: "POST",
+            headers: { "Content-Type": "application/json" },
+            body: JSON.stringify({ position: editorPosition() }),
+        });
+        const data = await response.json();
+        if (!response.ok) {
+            status.textContent = data.message;
+            return;
+        }
 
-        if (!data.black_turn) {
-            setTimeout(check, 500);
-        } else {
-            blackTurn = data.black_turn;
-            status.textContent = blackTurn ? "Black's turn (●)" : "White's turn (○)";
-            polling = false;
+        const lines = [data.exact ? "Solved" : `Depth ${data.depth}`];
+        if (data.pv.length > 0) {
+            lines.push(`Best line: ${data.pv.join(" ")}`);
+        }
+        if (data.moves.length === 0) {
+            lines.push(`${sideText(editorTurn())} has no move`);
         }
-    };
+        for (const m of data.moves) {
+            lines.push(`${m.move} ${m.score > 0 ? "+" : ""}${m.score}`);
+        }
+        editor.analysis.textContent = lines.join("\n");
+    }
+    catch (err) {
+        console.error("Analyse error:", err);
+    }
+}
 
-    check();
+document.getElementById("edit").addEventListener("click", startEditing);
+document.getElementById("cancel").addEventListener("click", stopEditing);
+document.getElementById("play").addEventListener("click", playPosition);
+document.getElementById("analyse").addEventListener("click", analysePosition);
+document.getElementById("clear").addEventListener("click", () => {
+    editor.squares.fill("");
+    editor.analysis.textContent = "";
+    drawEditor();
+});
+for (const input of document.querySelectorAll('input[name="turn"]')) {
+    input.addEventListener("change", () => { editor.analysis.textContent = ""; });
 }
 
+const events = new EventSource("/events");
+events.onmessage = (e) => handleEvent(JSON.parse(e.data));
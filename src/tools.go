@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/bits"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"Othello-Engine/board"
+	"Othello-Engine/repl"
+)
+
+// benchGame is a game between two depth 4 bots; bench searches positions
+// from it.
+const benchGame = "c5e6f3c4c3d3e3d2c2b6a7b5d1e2f4f5d6c1e1f1f2f6e7e8g5c6c7a6a5a4a3b4b3g1f7b8f8g8d7g6g4h6h5h4g3h3d8c8b2b1g7b7a2h7h8h2g2a8h1a1"
+
+// benchPlies are how far into benchGame the bench positions are, the last
+// few enough to be solved.
+var benchPlies = []int{0, 10, 20, 30, 40, 48}
+
+func perft(args []string) error {
+	flags := newFlagSet("perft", "")
+	maxDepth := flags.Int("depth", 9, "deepest count")
+	position := flags.String("position", "", "start position or moves from the start; empty for the normal start")
+	flags.Parse(args)
+
+	b, err := parseBoard(*position)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%5s %14s %12s\n", "depth", "lines", "time")
+	for d := 1; d <= *maxDepth; d++ {
+		start := time.Now()
+		n := board.Perft(b, d)
+		fmt.Printf("%5d %14d %12v\n", d, n, time.Since(start).Round(time.Microsecond))
+	}
+	return nil
+}
+
+func bench(args []string) error {
+	flags := newFlagSet("bench", "")
+	searchDepth := flags.Int("depth", depth, "search depth")
+	flags.Parse(args)
+
+	var total time.Duration
+	for _, ply := range benchPlies {
+		g, err := board.ParseTranscript(benchGame[:2*ply])
+		if err != nil {
+			return err
+		}
+		b := g.Board()
+
+		start := time.Now()
+		a, err := board.Analyze(context.Background(), b, *searchDepth, 0)
+		if err != nil {
+			return err
+		}
+		elapsed := time.Since(start)
+		total += elapsed
+
+		kind := fmt.Sprintf("depth %d", a.Depth)
+		if a.Exact {
+			kind = "solved"
+		}
+		best := "pass"
+		if len(a.Moves) > 0 {
+			best = fmt.Sprintf("%s %+d", board.SquareName(a.Moves[0].X, a.Moves[0].Y), a.Moves[0].Score)
+		}
+		fmt.Printf("ply %2d, %2d empty: %-9s %-8s %12v\n", ply, bits.OnesCount64(b.Empty()), kind, best, elapsed.Round(time.Microsecond))
+	}
+	fmt.Printf("total %v\n", total.Round(time.Microsecond))
+	return nil
+}
+
+func solve(args []string) error {
+	flags := newFlagSet("solve", "POSITION")
+	limit := flags.Duration("time", 0, "give up after this long; 0 for no limit")
+	flags.Parse(args)
+
+	b, err := parseBoard(strings.Join(flags.Args(), " "))
+	if err != nil {
+		return err
+	}
+	if empty := bits.OnesCount64(b.Empty()); empty > 24 {
+		fmt.Fprintf(os.Stderr, "Solving %d empty squares may take a very long time\n", empty)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if *limit > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *limit)
+		defer cancel()
+	}
+
+	a, err := board.AnalyzeExact(ctx, b)
+	if err != nil {
+		return fmt.Errorf("gave up: %w", err)
+	}
+	printAnalysis(b, a)
+	return nil
+}
+
+func analyze(args []string) error {
+	flags := newFlagSet("analyze", "POSITION")
+	searchDepth := flags.Int("depth", depth, "search depth; with -time the deepest it goes, 0 for no limit")
+	moveTime := flags.Duration("time", 0, "search time, e.g. 2s, instead of a fixed depth")
+	flags.Parse(args)
+
+	if *searchDepth < 0 || *searchDepth == 0 && *moveTime <= 0 {
+		return usageError{fmt.Errorf("depth must be at least 1 without -time")}
+	}
+	b, err := parseBoard(strings.Join(flags.Args(), " "))
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	a, err := board.Analyze(ctx, b, *searchDepth, *moveTime)
+	if err != nil {
+		return err
+	}
+	printAnalysis(b, a)
+	return nil
+}
+
+// printAnalysis shows the position and what a search made of it.
+func printAnalysis(b board.Board, a board.Analysis) {
+	b.DisplayBoard()
+	fmt.Println(board.Position(b))
+	repl.PrintAnalysis(os.Stdout, b, a)
+}
@@ -0,0 +1,81 @@
+// Tournament runs round-robin or gauntlet matches between bots and prints a
+// cross-table with Elo estimates.
+//
+//	go run ./tournament -players pengwin:6,greedy:6,random -openings 20
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"Othello-Engine/board"
+	"Othello-Engine/match"
+)
+
+func main() {
+	players := flag.String("players", "pengwin:4,greedy:4", "comma separated player specs (pengwin:D, greedy:D, level:N, random, nboard:D:PATH)")
+	gauntlet := flag.Bool("gauntlet", false, "play the first player against each of the others instead of round robin")
+	openings := flag.Int("openings", 10, "number of openings; each is played twice per pairing with colours swapped")
+	plies := flag.Int("plies", 8, "opening length in plies")
+	verify := flag.Int("verify", 6, "search depth used to check openings are balanced")
+	margin := flag.Int("margin", 2, "largest opening score accepted as balanced")
+	xot := flag.String("xot", "", "read openings from an XOT list instead of generating them")
+	workers := flag.Int("workers", runtime.NumCPU(), "games played in parallel")
+	timeControl := flag.String("time", "", "time control of every game, BASE[+INC][/BYOYOMI] such as 1m+1s; empty for none")
+	seed := flag.Int64("seed", 0, "seed of the openings and the players' random choices; 0 picks one")
+	record := flag.String("record", "games.jsonl", "file to append game records to")
+	flag.Parse()
+
+	tc, err := board.ParseTimeControl(*timeControl)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	var entrants []match.Entrant
+	for _, spec := range strings.Split(*players, ",") {
+		e, err := match.ParseEntrant(strings.TrimSpace(spec))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		entrants = append(entrants, e)
+	}
+	if len(entrants) < 2 {
+		fmt.Fprintln(os.Stderr, "need at least two players")
+		os.Exit(2)
+	}
+
+	if *seed == 0 {
+		*seed = board.NewSeed()
+	}
+	fmt.Fprintf(os.Stderr, "seed %d\n", *seed)
+
+	starts, err := match.Openings(*xot, board.NewRand(*seed), *openings, *plies, *verify, *margin)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	out, err := os.OpenFile(*record, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer out.Close()
+
+	t := match.Tournament{
+		Entrants: entrants,
+		Gauntlet: *gauntlet,
+		Openings: starts,
+		Workers:  *workers,
+		Time:     tc,
+		Seed:     *seed,
+		Record:   out,
+		Progress: os.Stderr,
+	}
+	t.Run().Print(os.Stdout)
+}
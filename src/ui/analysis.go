@@ -0,0 +1,343 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+
+	"Othello-Engine/board"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	// graphDepth is how deep each position on the evaluation graph is
+	// searched; the panel's own position goes on to MaxDepth.
+	graphDepth = 4
+	// graphLimit clips the graph, so a win found by the search doesn't
+	// flatten the rest of the game.
+	graphLimit = 100
+)
+
+var (
+	graphColor     = color.NRGBA{R: 240, G: 240, B: 240, A: 255}
+	graphAxisColor = color.NRGBA{R: 160, G: 160, B: 160, A: 255}
+)
+
+// AnalysisPanel shows what the engine makes of the current position: every
+// legal move with its score, the principal variation and the depth
+// searched, above a graph of the evaluation at each ply of the game. The
+// search runs in the background, a ply deeper at a time.
+type AnalysisPanel struct {
+	Container fyne.CanvasObject
+	Depth     *widget.Label
+	PV        *widget.Label
+	Moves     *widget.Label
+	Graph     *EvalGraph
+
+	shown     board.Board
+	positions []board.Board
+	scores    map[board.Board]float64 // graph scores, from Black's point of view
+	cancel    context.CancelFunc
+	gen       int // counts positions shown, so stale results are ignored
+}
+
+func NewAnalysisPanel() *AnalysisPanel {
+	p := &AnalysisPanel{
+		Depth:  widget.NewLabel(""),
+		PV:     widget.NewLabel(""),
+		Moves:  widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true}),
+		Graph:  NewEvalGraph(),
+		scores: make(map[board.Board]float64),
+	}
+	p.PV.Wrapping = fyne.TextWrapWord
+
+	title := widget.NewLabelWithStyle("Analysis", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	p.Container = container.NewBorder(
+		container.NewVBox(title, p.Depth, p.PV), p.Graph, nil, nil,
+		container.NewVScroll(p.Moves),
+	)
+	return p
+}
+
+// Show analyses the current position of g, unless it is already shown, and
+// brings the graph up to date with g's positions. It must be called on the
+// UI thread.
+func (p *AnalysisPanel) Show(g *board.Game) {
+	positions := g.Positions()
+	b := g.Board()
+	if b == p.shown && samePositions(positions, p.positions) {
+		p.Graph.SetCurrent(g.Ply())
+		return
+	}
+
+	p.Stop()
+	p.shown, p.positions = b, positions
+	p.Depth.SetText("Searching…")
+	p.PV.SetText("")
+	p.Moves.SetText("")
+	p.drawGraph(g.Ply())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	gen := p.gen
+	go p.deepen(ctx, gen, b)
+	go p.fillGraph(ctx, gen, g.Ply(), p.missing(g.Ply()))
+}
+
+// Stop cancels the searches in progress and ignores what they find.
+func (p *AnalysisPanel) Stop() {
+	p.gen++
+	if p.cancel != nil {
+		p.cancel()
+		p.cancel = nil
+	}
+}
+
+// deepen searches b a ply deeper each time, showing each result, until
+// MaxDepth or an exact solution.
+func (p *AnalysisPanel) deepen(ctx context.Context, gen int, b board.Board) {
+	if b.GameOver() {
+		black, white := b.Count()
+		fyne.Do(func() {
+			if p.gen == gen {
+				p.Depth.SetText(fmt.Sprintf("Game over %d-%d", black, white))
+			}
+		})
+		return
+	}
+
+	for depth := 1; depth <= MaxDepth; depth++ {
+		a, err := board.Analyze(ctx, b, depth, 0)
+		if err != nil {
+			return
+		}
+		fyne.Do(func() {
+			if p.gen == gen {
+				p.showAnalysis(b, a)
+			}
+		})
+		if a.Exact {
+			return
+		}
+	}
+}
+
+func (p *AnalysisPanel) showAnalysis(b board.Board, a board.Analysis) {
+	if a.Exact {
+		p.Depth.SetText(fmt.Sprintf("Solved, %d empty squares", a.Depth))
+	} else {
+		p.Depth.SetText(fmt.Sprintf("Depth %d", a.Depth))
+	}
+	p.PV.SetText(strings.Join(a.PV, " "))
+
+	if len(a.Moves) == 0 {
+		p.Moves.SetText(fmt.Sprintf("%s must pass", sideText(b.BlackTurn)))
+		return
+	}
+	lines := []string{fmt.Sprintf("Scores for %s", sideText(b.BlackTurn))}
+	for _, m := range a.Moves {
+		lines = append(lines, fmt.Sprintf("%s %+5d", board.SquareName(m.X, m.Y), m.Score))
+	}
+	p.Moves.SetText(strings.Join(lines, "\n"))
+}
+
+// fillGraph scores the positions the graph doesn't know yet.
+func (p *AnalysisPanel) fillGraph(ctx context.Context, gen, ply int, missing []board.Board) {
+	for _, b := range missing {
+		score, err := graphScore(ctx, b)
+		if err != nil {
+			return
+		}
+		fyne.Do(func() {
+			p.scores[b] = score
+			if p.gen == gen {
+				p.drawGraph(ply)
+			}
+		})
+	}
+}
+
+func (p *AnalysisPanel) drawGraph(ply int) {
+	scores := make([]float64, len(p.positions))
+	for i, b := range p.positions {
+		score, ok := p.scores[b]
+		if !ok {
+			score = math.NaN()
+		}
+		scores[i] = score
+	}
+	p.Graph.Scores, p.Graph.Current = scores, ply
+	p.Graph.Refresh()
+}
+
+// missing lists the positions the graph has no score for, nearest ply
+// first.
+func (p *AnalysisPanel) missing(ply int) []board.Board {
+	var missing []board.Board
+	for _, i := range nearestFirst(len(p.positions), ply) {
+		if _, ok := p.scores[p.positions[i]]; !ok {
+			missing = append(missing, p.positions[i])
+		}
+	}
+	return missing
+}
+
+// graphScore is the engine's score for b from Black's point of view: a
+// shallow search, or the disc difference once the game is over.
+func graphScore(ctx context.Context, b board.Board) (float64, error) {
+	if b.GameOver() {
+		black, white := b.Count()
+		return float64(black - white), nil
+	}
+
+	a, err := board.Analyze(ctx, b, graphDepth, 0)
+	if err != nil {
+		return 0, err
+	}
+	if len(a.Moves) == 0 {
+		b.BlackTurn = !b.BlackTurn
+		return graphScore(ctx, b)
+	}
+
+	score := float64(a.Moves[0].Score)
+	if !b.BlackTurn {
+		score = -score
+	}
+	return max(-graphLimit, min(graphLimit, score)), nil
+}
+
+// nearestFirst lists 0..n-1 by distance from i.
+func nearestFirst(n, i int) []int {
+	order := make([]int, 0, n)
+	for d := 0; len(order) < n; d++ {
+		if i-d >= 0 && i-d < n {
+			order = append(order, i-d)
+		}
+		if d > 0 && i+d < n {
+			order = append(order, i+d)
+		}
+	}
+	return order
+}
+
+func samePositions(a, b []board.Board) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// EvalGraph plots a score for each ply of a game, Black's advantage
+// upwards, and marks the current ply.
+type EvalGraph struct {
+	widget.BaseWidget
+	Scores  []float64 // from Black's point of view; NaN where not known yet
+	Current int
+}
+
+func NewEvalGraph() *EvalGraph {
+	g := &EvalGraph{}
+	g.ExtendBaseWidget(g)
+	return g
+}
+
+// SetCurrent moves the mark to another ply.
+func (g *EvalGraph) SetCurrent(ply int) {
+	g.Current = ply
+	g.Refresh()
+}
+
+func (g *EvalGraph) CreateRenderer() fyne.WidgetRenderer {
+	r := &graphRenderer{
+		graph:      g,
+		background: canvas.NewRectangle(graphColor),
+		axis:       canvas.NewLine(graphAxisColor),
+		cursor:     canvas.NewLine(lastMoveColor),
+	}
+	r.Refresh()
+	return r
+}
+
+type graphRenderer struct {
+	graph      *EvalGraph
+	background *canvas.Rectangle
+	axis       *canvas.Line
+	cursor     *canvas.Line
+	segments   []*canvas.Line
+}
+
+func (r *graphRenderer) Layout(size fyne.Size) {
+	r.background.Resize(size)
+	mid := size.Height / 2
+	r.axis.Position1, r.axis.Position2 = fyne.NewPos(0, mid), fyne.NewPos(size.Width, mid)
+
+	scores := r.graph.Scores
+	scale := 1.0
+	for _, s := range scores {
+		if !math.IsNaN(s) {
+			scale = max(scale, math.Abs(s))
+		}
+	}
+	x := func(i int) float32 {
+		if len(scores) < 2 {
+			return 0
+		}
+		return size.Width * float32(i) / float32(len(scores)-1)
+	}
+	y := func(s float64) float32 {
+		return mid - float32(s/scale)*mid*0.9
+	}
+
+	for i, line := range r.segments {
+		a, b := scores[i], scores[i+1]
+		if math.IsNaN(a) || math.IsNaN(b) {
+			line.Hide()
+			continue
+		}
+		line.Position1, line.Position2 = fyne.NewPos(x(i), y(a)), fyne.NewPos(x(i+1), y(b))
+		line.Show()
+	}
+
+	cx := x(r.graph.Current)
+	r.cursor.Position1, r.cursor.Position2 = fyne.NewPos(cx, 0), fyne.NewPos(cx, size.Height)
+}
+
+func (r *graphRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(200, 100)
+}
+
+func (r *graphRenderer) Refresh() {
+	n := max(len(r.graph.Scores)-1, 0)
+	for len(r.segments) < n {
+		line := canvas.NewLine(color.Black)
+		line.StrokeWidth = 2
+		r.segments = append(r.segments, line)
+	}
+	r.segments = r.segments[:n]
+
+	r.Layout(r.graph.Size())
+	for _, o := range r.Objects() {
+		o.Refresh()
+	}
+}
+
+func (r *graphRenderer) Objects() []fyne.CanvasObject {
+	objects := []fyne.CanvasObject{r.background, r.axis}
+	for _, line := range r.segments {
+		objects = append(objects, line)
+	}
+	return append(objects, r.cursor)
+}
+
+func (r *graphRenderer) Destroy() {}
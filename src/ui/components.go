@@ -2,8 +2,10 @@ package ui
 
 import (
 	"Othello-Engine/board"
+	"context"
 	"fmt"
 	"image/color"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
@@ -14,12 +16,53 @@ import (
 
 type BoardUI struct {
 	Grid    *fyne.Container
-	Discs   [][]fyne.CanvasObject
-	Board   *board.Board
+	Discs   [][]*canvas.Circle
+	Game    *board.Game
 	Status  *widget.Label
 	Updater func()
+
+	// Config is who plays the current game; Black and White are the bots
+	// it makes, nil for a human.
+	Config       GameConfig
+	Black, White board.Player
+	// Activity spins while a bot is thinking.
+	Activity *widget.Activity
+	Settings Settings
+
+	squares    [][]*canvas.Rectangle
+	hints      [][]*canvas.Circle
+	lastMove   uint64 // bit of the last move played
+	flipped    uint64 // discs it turned
+	animations []*fyne.Animation
+
+	thinking bool
+	cancel   context.CancelFunc // stops the bot's search
+	gen      int                // counts new games and history changes, so a stale bot move is ignored
+	paused   bool               // a game between bots stopped by undo or redo; see Resume
+
+	editing  bool        // clicks set up a position instead of playing
+	edited   board.Board // the position being set up
+	editBar  fyne.CanvasObject
+	editTurn *widget.RadioGroup
 }
 
+// Settings switch the board's visual aids on and off.
+type Settings struct {
+	Hints    bool // mark the squares the human may play
+	LastMove bool // outline the last move
+	Animate  bool // fade flipped discs to their new colour
+}
+
+var DefaultSettings = Settings{Hints: true, LastMove: true, Animate: true}
+
+var (
+	squareColor   = color.NRGBA{R: 0, G: 160, B: 0, A: 255}
+	hintColor     = color.NRGBA{A: 80}
+	lastMoveColor = color.NRGBA{R: 220, A: 255}
+)
+
+const flipDuration = 300 * time.Millisecond
+
 func NewSquareGrid(cols int) fyne.Layout {
 	return &SquareGridLayout{Cols: cols}
 }
@@ -43,34 +86,62 @@ func (g *SquareGridLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
 	return fyne.NewSize(cellSize*float32(g.Cols), cellSize*float32(rows))
 }
 
-func CreateBoardUI(b *board.Board, status *widget.Label) *BoardUI {
+// scaledLayout centres its objects at a fraction of the cell's size, so
+// discs grow with the window.
+type scaledLayout struct{ Scale float32 }
+
+func (l scaledLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
+	side := min(size.Width, size.Height) * l.Scale
+	for _, obj := range objects {
+		obj.Resize(fyne.NewSize(side, side))
+		obj.Move(fyne.NewPos((size.Width-side)/2, (size.Height-side)/2))
+	}
+}
+
+func (l scaledLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
+	return fyne.NewSize(0, 0)
+}
+
+func CreateBoardUI(g *board.Game, status *widget.Label) *BoardUI {
 	grid := container.New(NewSquareGrid(8))
-	discs := make([][]fyne.CanvasObject, 8)
-	boardUI := &BoardUI{Grid: grid, Discs: discs, Board: b, Status: status}
+	discs := make([][]*canvas.Circle, 8)
+	boardUI := &BoardUI{
+		Grid:     grid,
+		Discs:    discs,
+		Game:     g,
+		Status:   status,
+		Activity: widget.NewActivity(),
+		Settings: DefaultSettings,
+		squares:  make([][]*canvas.Rectangle, 8),
+		hints:    make([][]*canvas.Circle, 8),
+	}
+	boardUI.Activity.Hide()
 
 	for r := 0; r < 8; r++ {
-		discs[r] = make([]fyne.CanvasObject, 8)
+		discs[r] = make([]*canvas.Circle, 8)
+		boardUI.squares[r] = make([]*canvas.Rectangle, 8)
+		boardUI.hints[r] = make([]*canvas.Circle, 8)
 		for c := 0; c < 8; c++ {
 			row, col := r, c
-			bg := canvas.NewRectangle(color.NRGBA{R: 0, G: 160, B: 0, A: 255})
+			bg := canvas.NewRectangle(squareColor)
 			bg.StrokeColor = color.Black
 			bg.StrokeWidth = 1
 
-			disc := discCircle(b, row, col)
+			disc := canvas.NewCircle(color.Transparent)
+			hint := canvas.NewCircle(hintColor)
+			hint.Hide()
 			discs[row][col] = disc
+			boardUI.squares[row][col] = bg
+			boardUI.hints[row][col] = hint
 
-			cell := container.NewStack(bg, container.NewCenter(disc))
+			cell := container.NewStack(
+				bg,
+				container.New(scaledLayout{Scale: 0.25}, hint),
+				container.New(scaledLayout{Scale: 0.8}, disc),
+			)
 
 			btn := widget.NewButton("", func() {
-				err := b.PlayXY(col, row)
-				if err != nil {
-					status.SetText(fmt.Sprintf("Invalid move at %c%d", 'A'+col, 8-row))
-					return
-				}
-				boardUI.UpdateBoard()
-				if b.GameOver() {
-					status.SetText("Game Over!")
-				}
+				boardUI.click(col, row)
 			})
 			btn.Importance = widget.LowImportance
 
@@ -83,21 +154,46 @@ func CreateBoardUI(b *board.Board, status *widget.Label) *BoardUI {
 }
 
 func (bui *BoardUI) UpdateBoard() {
+	for _, a := range bui.animations {
+		a.Stop()
+	}
+	bui.animations = nil
+
+	b := bui.Game.Board()
+	if bui.editing {
+		b = bui.edited
+	}
+	var hints uint64
+	if bui.Settings.Hints && !bui.editing && !bui.thinking && bui.botToMove() == nil {
+		hints = b.LegalMoves()
+	}
+
 	for r := 0; r < 8; r++ {
 		for c := 0; c < 8; c++ {
-			// Replace disc CanvasObject in container stack
-			newDisc := discCircle(bui.Board, r, c)
-			bui.Discs[r][c] = newDisc
-
-			cellStack := bui.Grid.Objects[r*8+c].(*fyne.Container).Objects[0].(*fyne.Container) // the container.NewStack
-			// first is bg (Rectangle), second is Center(disc)
-			cellStack.Objects[1] = container.NewCenter(newDisc)
-			cellStack.Refresh()
+			mask := board.SqureToBit(c, r)
+
+			paintDisc(bui.Discs[r][c], &b, mask)
+
+			bg := bui.squares[r][c]
+			if bui.Settings.LastMove && !bui.editing && bui.lastMove == mask {
+				bg.StrokeColor, bg.StrokeWidth = lastMoveColor, 3
+			} else {
+				bg.StrokeColor, bg.StrokeWidth = color.Black, 1
+			}
+			bg.Refresh()
+
+			if hints&mask != 0 {
+				bui.hints[r][c].Show()
+			} else {
+				bui.hints[r][c].Hide()
+			}
 		}
 	}
 
 	// Update turn status
-	if bui.Board.BlackTurn {
+	if bui.editing {
+		bui.Status.SetText("Editing: click a square to change it")
+	} else if b.BlackTurn {
 		bui.Status.SetText("Black's turn (●)")
 	} else {
 		bui.Status.SetText("White's turn (○)")
@@ -105,42 +201,135 @@ func (bui *BoardUI) UpdateBoard() {
 	bui.Status.Refresh()
 }
 
-func discCircle(b *board.Board, row, col int) fyne.CanvasObject {
-	idx := row*8 + col
-	mask := uint64(1) << idx
-	const discSize = 24
+// play makes a move for the side to move, redraws the board and animates
+// the discs it turned.
+func (bui *BoardUI) play(x, y int) error {
+	b := bui.Game.Board()
+	flipped := b.Flips(x, y)
+	if err := bui.Game.PlayXY(x, y); err != nil {
+		return err
+	}
+	bui.lastMove = board.SqureToBit(x, y)
+	bui.flipped = flipped
+	bui.Updater()
+
+	if bui.Settings.Animate {
+		bui.animateFlips()
+	}
+	return nil
+}
+
+// animateFlips fades each disc turned by the last move from its old colour.
+func (bui *BoardUI) animateFlips() {
+	for r := 0; r < 8; r++ {
+		for c := 0; c < 8; c++ {
+			if bui.flipped&board.SqureToBit(c, r) == 0 {
+				continue
+			}
+			disc := bui.Discs[r][c]
+			to := disc.FillColor
+			from := color.Color(color.Black)
+			if to == color.Black {
+				from = color.White
+			}
 
+			a := canvas.NewColorRGBAAnimation(from, to, flipDuration, func(c color.Color) {
+				disc.FillColor = c
+				disc.Refresh()
+			})
+			bui.animations = append(bui.animations, a)
+			a.Start()
+		}
+	}
+}
+
+// click plays the human's move at a square and lets a bot reply.
+func (bui *BoardUI) click(col, row int) {
+	if bui.editing {
+		bui.editSquare(col, row)
+		return
+	}
+	if bui.thinking || bui.botToMove() != nil {
+		return
+	}
+
+	if err := bui.play(col, row); err != nil {
+		bui.Status.SetText(fmt.Sprintf("Invalid move at %s", board.SquareName(col, row)))
+		return
+	}
+	bui.next()
+}
+
+// paintDisc colours the disc on the square mask, hiding it if the square
+// is empty.
+func paintDisc(disc *canvas.Circle, b *board.Board, mask uint64) {
 	switch {
 	case b.Black&mask != 0:
-		c := canvas.NewCircle(color.Black)
-		c.Resize(fyne.NewSize(discSize, discSize))
-		return c
+		disc.FillColor = color.Black
+		disc.StrokeWidth = 0
+		disc.Show()
 	case b.White&mask != 0:
-		c := canvas.NewCircle(color.White)
-		c.StrokeColor = color.Black
-		c.StrokeWidth = 1
-		c.Resize(fyne.NewSize(discSize, discSize))
-		return c
+		disc.FillColor = color.White
+		disc.StrokeColor = color.Black
+		disc.StrokeWidth = 1
+		disc.Show()
 	default:
-		empty := canvas.NewRectangle(color.Transparent)
-		empty.SetMinSize(fyne.NewSize(discSize, discSize))
-		return empty
+		disc.Hide()
+	}
+	disc.Refresh()
+}
+
+// SettingsMenu toggles the board's Settings.
+func SettingsMenu(bui *BoardUI) *fyne.Menu {
+	menu := fyne.NewMenu("Settings")
+	toggle := func(label string, setting *bool) *fyne.MenuItem {
+		item := fyne.NewMenuItem(label, nil)
+		item.Checked = *setting
+		item.Action = func() {
+			*setting = !*setting
+			item.Checked = *setting
+			menu.Refresh()
+			bui.UpdateBoard()
+			if bui.thinking {
+				bui.Status.SetText(fmt.Sprintf("%s is thinking…", sideText(bui.Game.Board().BlackTurn)))
+			}
+		}
+		return item
 	}
+
+	menu.Items = []*fyne.MenuItem{
+		toggle("Show legal moves", &bui.Settings.Hints),
+		toggle("Highlight last move", &bui.Settings.LastMove),
+		toggle("Animate flips", &bui.Settings.Animate),
+	}
+	return menu
 }
 
 func LaunchGame() {
-	b := board.NewBoard()
+	g := board.NewGame(board.NewBoard())
 	a := app.New()
 	w := a.NewWindow("Othello")
 
 	status := widget.NewLabel("Black's turn (●)")
 
-	boardUI := CreateBoardUI(&b, status)
-	content := container.NewVBox(status, boardUI.Grid)
+	boardUI := CreateBoardUI(g, status)
+	newGame := widget.NewButton("New game…", func() {
+		ShowNewGameDialog(w, boardUI)
+	})
+	top := container.NewBorder(nil, nil, nil, container.NewHBox(boardUI.Activity, newGame), status)
+	editBar := EditBar(w, boardUI)
+	analysis := NewAnalysisPanel()
+	boardUI.Updater = func() {
+		boardUI.UpdateBoard()
+		analysis.Show(boardUI.Game)
+	}
+	content := container.NewBorder(nil, nil, nil, analysis.Container, container.NewVBox(top, editBar, boardUI.Grid))
 
 	boardUI.UpdateBoard() // initial fill
+	boardUI.NewGame(DefaultGame)
 
+	w.SetMainMenu(MainMenu(w, boardUI))
 	w.SetContent(content)
-	w.Resize(fyne.NewSize(480, 520))
+	w.Resize(fyne.NewSize(720, 520))
 	w.ShowAndRun()
 }
@@ -0,0 +1,269 @@
+//go:build ci
+
+// The UI tests need fyne's software driver, which the ci build tag selects
+// in place of OpenGL, so they run without a display:
+//
+//	go test -tags ci ./ui
+package ui
+
+import (
+	"image/color"
+	"strings"
+	"testing"
+	"time"
+
+	"Othello-Engine/board"
+	"Othello-Engine/internal/testbot"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+)
+
+var humans = GameConfig{Black: PlayerConfig{Kind: "Human"}, White: PlayerConfig{Kind: "Human"}}
+
+// newTestBoard shows a board in a test window, with a game between cfg's
+// players under way. Flips aren't animated, so discs take their colour at
+// once.
+func newTestBoard(t *testing.T, cfg GameConfig) (*BoardUI, fyne.Window) {
+	t.Helper()
+	test.NewTempApp(t)
+
+	bui := CreateBoardUI(board.NewGame(board.NewBoard()), widget.NewLabel(""))
+	bui.Settings.Animate = false
+	w := test.NewWindow(bui.Grid)
+	t.Cleanup(w.Close)
+	w.Resize(fyne.NewSize(320, 320))
+
+	if err := bui.NewGame(cfg); err != nil {
+		t.Fatal(err)
+	}
+	return bui, w
+}
+
+// tap clicks the middle of a square.
+func tap(t *testing.T, w fyne.Window, bui *BoardUI, square string) {
+	t.Helper()
+	x, y, err := board.ParseSquare(square)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cell := bui.Grid.Size().Width / 8
+	pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(bui.Grid)
+	test.TapCanvas(w.Canvas(), pos.Add(fyne.NewPos((float32(x)+0.5)*cell, (float32(y)+0.5)*cell)))
+}
+
+// discAt reads the colour drawn on a square: "black", "white" or "" for
+// none.
+func discAt(t *testing.T, bui *BoardUI, square string) string {
+	t.Helper()
+	x, y, err := board.ParseSquare(square)
+	if err != nil {
+		t.Fatal(err)
+	}
+	disc := bui.Discs[y][x]
+	switch {
+	case !disc.Visible():
+		return ""
+	case disc.FillColor == color.Black:
+		return "black"
+	case disc.FillColor == color.White:
+		return "white"
+	}
+	t.Fatalf("%s is %v", square, disc.FillColor)
+	return ""
+}
+
+// checkDiscs compares every square drawn with b.
+func checkDiscs(t *testing.T, bui *BoardUI, b board.Board) {
+	t.Helper()
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			square, mask := board.SquareName(x, y), board.SqureToBit(x, y)
+			want := ""
+			switch {
+			case b.Black&mask != 0:
+				want = "black"
+			case b.White&mask != 0:
+				want = "white"
+			}
+			if got := discAt(t, bui, square); got != want {
+				t.Errorf("%s drawn %q, want %q", square, got, want)
+			}
+		}
+	}
+}
+
+func TestStartPosition(t *testing.T) {
+	bui, _ := newTestBoard(t, humans)
+
+	checkDiscs(t, bui, board.NewBoard())
+	if got := bui.Status.Text; got != "Black's turn (●)" {
+		t.Errorf("status = %q", got)
+	}
+
+	hints := 0
+	for y := range bui.hints {
+		for _, hint := range bui.hints[y] {
+			if hint.Visible() {
+				hints++
+			}
+		}
+	}
+	if hints != 4 {
+		t.Errorf("%d legal moves marked, want 4", hints)
+	}
+}
+
+func TestClickPlaysMove(t *testing.T) {
+	bui, w := newTestBoard(t, humans)
+
+	want := board.NewBoard()
+	if err := want.Play("c5"); err != nil {
+		t.Fatal(err)
+	}
+
+	tap(t, w, bui, "c5")
+	if got := bui.Game.Board(); got != want {
+		t.Fatalf("board after c5 = %+v, want %+v", got, want)
+	}
+	checkDiscs(t, bui, want)
+	if got := discAt(t, bui, "c5"); got != "black" {
+		t.Errorf("c5 drawn %q", got)
+	}
+	if got := bui.Status.Text; got != "White's turn (○)" {
+		t.Errorf("status = %q", got)
+	}
+}
+
+func TestIllegalClickLeavesBoardUnchanged(t *testing.T) {
+	bui, w := newTestBoard(t, humans)
+	before := bui.Game.Board()
+
+	for _, square := range []string{"a1", "d4", "c3"} {
+		tap(t, w, bui, square)
+		if got := bui.Game.Board(); got != before {
+			t.Fatalf("board after %s = %+v, want %+v", square, got, before)
+		}
+		checkDiscs(t, bui, before)
+		if got := bui.Status.Text; got != "Invalid move at "+square {
+			t.Errorf("status after %s = %q", square, got)
+		}
+	}
+	if len(bui.Game.Moves()) != 0 {
+		t.Errorf("moves = %v", bui.Game.Moves())
+	}
+}
+
+func TestClickIgnoredWhileBotThinks(t *testing.T) {
+	bui, w := newTestBoard(t, humans)
+	gate := make(testbot.Gate)
+	bui.White = gate
+
+	tap(t, w, bui, "c5")
+	if !bui.thinking || !strings.Contains(bui.Status.Text, "thinking") {
+		t.Fatalf("bot not thinking; status %q", bui.Status.Text)
+	}
+
+	after := bui.Game.Board()
+	for _, square := range []string{"c4", "c6", "e6"} {
+		tap(t, w, bui, square)
+	}
+	if got := bui.Game.Board(); got != after {
+		t.Errorf("board changed while the bot was thinking: %+v", got)
+	}
+
+	// The bot's move comes too late to be played.
+	bui.stopBot()
+	close(gate)
+}
+
+func TestUndoRedo(t *testing.T) {
+	bui, w := newTestBoard(t, humans)
+
+	tap(t, w, bui, "c5")
+	after := bui.Game.Board()
+	tap(t, w, bui, "c6")
+
+	bui.Undo()
+	checkDiscs(t, bui, after)
+	bui.Undo()
+	checkDiscs(t, bui, board.NewBoard())
+	if bui.Game.CanUndo() {
+		t.Errorf("can undo past the start")
+	}
+
+	bui.Redo()
+	checkDiscs(t, bui, after)
+	if got := bui.Status.Text; got != "White's turn (○)" {
+		t.Errorf("status = %q", got)
+	}
+}
+
+func TestUndoRedoBetweenBots(t *testing.T) {
+	bui, w := newTestBoard(t, humans)
+	tap(t, w, bui, "c5")
+	after := bui.Game.Board()
+	tap(t, w, bui, "c6")
+	end := bui.Game.Board()
+
+	// Bots that move at once: nothing but the pause keeps them from
+	// playing over the moves to redo.
+	bui.Black, bui.White = board.RandomPlayer{}, board.RandomPlayer{}
+
+	bui.Undo()
+	checkDiscs(t, bui, after)
+	bui.Undo()
+	checkDiscs(t, bui, board.NewBoard())
+	time.Sleep(50 * time.Millisecond)
+	bui.Redo()
+	checkDiscs(t, bui, after)
+	bui.Redo()
+	checkDiscs(t, bui, end)
+	if bui.Game.CanRedo() || bui.thinking {
+		t.Errorf("can redo %v, thinking %v at the end of the history", bui.Game.CanRedo(), bui.thinking)
+	}
+
+	bui.Resume()
+	deadline := time.Now().Add(5 * time.Second)
+	for ply := 2; ply == 2 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+		fyne.DoAndWait(func() { ply = bui.Game.Ply() })
+	}
+	fyne.DoAndWait(func() {
+		if bui.Game.Ply() == 2 {
+			t.Error("bots did not play on after Resume")
+		}
+		bui.stopBot()
+	})
+}
+
+func TestPastePositionValidates(t *testing.T) {
+	bui, w := newTestBoard(t, humans)
+	fyne.CurrentApp().Clipboard().SetContent(strings.Repeat("-", 64) + " *")
+	pastePosition(w, bui)
+	checkDiscs(t, bui, board.NewBoard())
+}
+
+func TestEditorCyclesSquares(t *testing.T) {
+	bui, w := newTestBoard(t, humans)
+	bui.Edit()
+
+	for _, want := range []string{"black", "white", ""} {
+		tap(t, w, bui, "a1")
+		if got := discAt(t, bui, "a1"); got != want {
+			t.Errorf("a1 drawn %q, want %q", got, want)
+		}
+	}
+	if len(bui.Game.Moves()) != 0 {
+		t.Errorf("editing played %v", bui.Game.Moves())
+	}
+
+	// Black's d4 goes white, then empty, and a position with an empty
+	// centre square can't be played from.
+	tap(t, w, bui, "d4")
+	tap(t, w, bui, "d4")
+	if err := bui.PlayPosition(); err == nil {
+		t.Errorf("played from a position with an empty centre")
+	}
+}
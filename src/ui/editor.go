@@ -0,0 +1,114 @@
+package ui
+
+import (
+	"Othello-Engine/board"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+)
+
+// EditBar makes the controls for setting up a position, shown only while
+// editing: the side to move, and what to do with the position.
+func EditBar(w fyne.Window, bui *BoardUI) fyne.CanvasObject {
+	turn := widget.NewRadioGroup([]string{"Black", "White"}, func(side string) {
+		bui.edited.BlackTurn = side == "Black"
+	})
+	turn.Horizontal = true
+	turn.Required = true
+
+	clear := widget.NewButton("Clear", func() {
+		bui.edited.Black, bui.edited.White = 0, 0
+		bui.UpdateBoard()
+	})
+	cancel := widget.NewButton("Cancel", func() {
+		bui.stopEditing()
+		bui.UpdateBoard()
+		bui.next()
+	})
+	analyse := widget.NewButton("Analyse", func() {
+		showError(bui.AnalysePosition(), w)
+	})
+	play := widget.NewButton("Play against bot", func() {
+		showError(bui.PlayPosition(), w)
+	})
+	play.Importance = widget.HighImportance
+
+	bar := container.NewHBox(widget.NewLabel("To move:"), turn, layout.NewSpacer(), clear, cancel, analyse, play)
+	bar.Hide()
+	bui.editBar, bui.editTurn = bar, turn
+	return bar
+}
+
+// Edit lets clicks set up a position, starting from the one shown. The
+// game is paused until the position is played or editing is cancelled.
+func (bui *BoardUI) Edit() {
+	bui.stopBot()
+	bui.editing = true
+	bui.edited = bui.Game.Board()
+	if bui.editTurn != nil {
+		bui.editTurn.SetSelected(sideText(bui.edited.BlackTurn))
+	}
+	if bui.editBar != nil {
+		bui.editBar.Show()
+	}
+	bui.UpdateBoard()
+}
+
+func (bui *BoardUI) stopEditing() {
+	bui.editing = false
+	if bui.editBar != nil {
+		bui.editBar.Hide()
+	}
+}
+
+// editSquare turns an empty square black, a black one white and a white
+// one empty.
+func (bui *BoardUI) editSquare(col, row int) {
+	mask := board.SqureToBit(col, row)
+	b := &bui.edited
+	switch {
+	case b.Black&mask != 0:
+		b.Black &^= mask
+		b.White |= mask
+	case b.White&mask != 0:
+		b.White &^= mask
+	default:
+		b.Black |= mask
+	}
+	bui.UpdateBoard()
+}
+
+// PlayPosition starts a game from the edited position, the human playing
+// the side to move against the current bot, or the default one if both
+// sides were human.
+func (bui *BoardUI) PlayPosition() error {
+	start := bui.edited
+	if err := start.Validate(); err != nil {
+		return err
+	}
+
+	bot := DefaultGame.White
+	for _, cfg := range []PlayerConfig{bui.Config.Black, bui.Config.White} {
+		if cfg.Kind != "Human" {
+			bot = cfg
+		}
+	}
+	cfg := GameConfig{Black: PlayerConfig{Kind: "Human"}, White: bot}
+	if !start.BlackTurn {
+		cfg.Black, cfg.White = cfg.White, cfg.Black
+	}
+	return bui.NewGameFrom(cfg, start)
+}
+
+// AnalysePosition loads the edited position with humans on both sides, to
+// study it without a bot moving.
+func (bui *BoardUI) AnalysePosition() error {
+	start := bui.edited
+	if err := start.Validate(); err != nil {
+		return err
+	}
+	human := PlayerConfig{Kind: "Human"}
+	return bui.NewGameFrom(GameConfig{Black: human, White: human}, start)
+}
@@ -0,0 +1,297 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"Othello-Engine/board"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Kinds of player offered for each side.
+var PlayerKinds = []string{"Human", "Level", "Pengwin", "Greedy", "Random"}
+
+const MaxDepth = 10
+
+// PlayerConfig says who plays one side: a human, or a bot searching to
+// Depth. For a Level bot, Depth is the level, as in board.Levels.
+type PlayerConfig struct {
+	Kind  string
+	Depth int
+}
+
+type GameConfig struct {
+	Black, White PlayerConfig
+}
+
+// DefaultGame is a human, as black, against Pengwin.
+var DefaultGame = GameConfig{
+	Black: PlayerConfig{Kind: "Human"},
+	White: PlayerConfig{Kind: "Pengwin", Depth: 6},
+}
+
+// NewPlayer builds the bot for one side, or nil for a human. progress, if
+// set, is told how far the bot's search has got. The bots draw from the
+// global random source, as games here are not replayed from a seed.
+func NewPlayer(cfg PlayerConfig, side string, progress func(done, total int)) (board.Player, error) {
+	switch cfg.Kind {
+	case "Human":
+		return nil, nil
+	case "Level":
+		bot, err := board.NewLevel(cfg.Depth, side)
+		if err != nil {
+			return nil, err
+		}
+		bot.Progress = progress
+		return bot, nil
+	case "Pengwin":
+		bot := board.NewPengwin(cfg.Depth, side)
+		bot.Progress = progress
+		return bot, nil
+	case "Greedy":
+		bot := board.NewGreedy(cfg.Depth, side)
+		bot.Progress = progress
+		return bot, nil
+	case "Random":
+		return board.RandomPlayer{}, nil
+	}
+	return nil, fmt.Errorf("unknown player %q", cfg.Kind)
+}
+
+func sideText(black bool) string {
+	if black {
+		return "Black"
+	}
+	return "White"
+}
+
+// NewGame starts a game from the opening position with the given players.
+// A bot still thinking about the previous game is stopped.
+func (bui *BoardUI) NewGame(cfg GameConfig) error {
+	return bui.NewGameFrom(cfg, board.NewBoard())
+}
+
+// NewGameFrom is NewGame starting from another position.
+func (bui *BoardUI) NewGameFrom(cfg GameConfig, start board.Board) error {
+	progress := func(done, total int) {
+		fyne.Do(func() {
+			if bui.thinking {
+				bui.Status.SetText(fmt.Sprintf("%s is thinking… %d/%d", sideText(bui.Game.Board().BlackTurn), done, total))
+			}
+		})
+	}
+
+	black, err := NewPlayer(cfg.Black, "black", progress)
+	if err != nil {
+		return err
+	}
+	white, err := NewPlayer(cfg.White, "white", progress)
+	if err != nil {
+		return err
+	}
+
+	bui.Config = cfg
+	bui.Black, bui.White = black, white
+	bui.Load(board.NewGame(start))
+	return nil
+}
+
+// Load shows g, played on by the current players.
+func (bui *BoardUI) Load(g *board.Game) {
+	bui.stopBot()
+	*bui.Game = *g
+	bui.paused = false
+	bui.showHistory()
+}
+
+// Undo takes back moves until a human is to move, and Redo plays them
+// again, so a bot's reply goes with the move it answered. Between two
+// bots they go a move at a time and pause the game until Resume.
+func (bui *BoardUI) Undo() {
+	bui.stopBot()
+	for bui.Game.Undo() && bui.skipBot() {
+	}
+	bui.paused = bui.Black != nil && bui.White != nil
+	bui.showHistory()
+}
+
+func (bui *BoardUI) Redo() {
+	bui.stopBot()
+	for bui.Game.Redo() && bui.skipBot() {
+	}
+	bui.paused = bui.Black != nil && bui.White != nil
+	bui.showHistory()
+}
+
+// Resume lets the bots play on from a game paused by undo or redo.
+func (bui *BoardUI) Resume() {
+	if !bui.paused {
+		return
+	}
+	bui.paused = false
+	bui.next()
+}
+
+// showHistory draws a position reached by undo, redo or loading, without
+// animation, and lets a bot move if it is its turn, unless the game is
+// paused. It ends any editing.
+func (bui *BoardUI) showHistory() {
+	bui.stopEditing()
+	bui.lastMove, bui.flipped = 0, 0
+	if x, y, ok := bui.Game.LastMove(); ok {
+		bui.lastMove = board.SqureToBit(x, y)
+	}
+	bui.Updater()
+	if b := bui.Game.Board(); bui.paused && !b.GameOver() {
+		bui.Status.SetText("Paused: Edit > Resume lets the bots play on")
+		return
+	}
+	bui.next()
+}
+
+// botToMove returns the bot whose turn it is, or nil for a human.
+func (bui *BoardUI) botToMove() board.Player {
+	if bui.Game.Board().BlackTurn {
+		return bui.Black
+	}
+	return bui.White
+}
+
+// skipBot reports whether undo and redo step on past the position, as
+// they do when a bot is to move against a human. With bots on both sides
+// they go one move at a time.
+func (bui *BoardUI) skipBot() bool {
+	return bui.botToMove() != nil && (bui.Black == nil || bui.White == nil)
+}
+
+func (bui *BoardUI) setThinking(thinking bool) {
+	bui.thinking = thinking
+	if thinking {
+		bui.Activity.Show()
+		bui.Activity.Start()
+	} else {
+		bui.Activity.Stop()
+		bui.Activity.Hide()
+	}
+}
+
+// stopBot cancels the bot's search, if any, and makes sure its move is
+// never played.
+func (bui *BoardUI) stopBot() {
+	bui.gen++
+	if bui.cancel != nil {
+		bui.cancel()
+		bui.cancel = nil
+	}
+	bui.setThinking(false)
+}
+
+// next reports the end of the game, or starts the bot whose turn it is on
+// a copy of the board. Its move is played back on the UI thread.
+func (bui *BoardUI) next() {
+	b := bui.Game.Board()
+	if b.GameOver() {
+		black, white := b.Count()
+		switch {
+		case black > white:
+			bui.Status.SetText(fmt.Sprintf("Game over: Black wins %d-%d", black, white))
+		case white > black:
+			bui.Status.SetText(fmt.Sprintf("Game over: White wins %d-%d", black, white))
+		default:
+			bui.Status.SetText(fmt.Sprintf("Game over: draw %d-%d", black, white))
+		}
+		return
+	}
+
+	bot := bui.botToMove()
+	if bot == nil {
+		return
+	}
+
+	bui.setThinking(true)
+	bui.UpdateBoard()
+	bui.Status.SetText(fmt.Sprintf("%s is thinking…", sideText(b.BlackTurn)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	bui.cancel = cancel
+	gen := bui.gen
+	go func() {
+		x, y, ok := board.GetMoveContext(ctx, bot, &b)
+		fyne.Do(func() {
+			if bui.gen != gen {
+				return
+			}
+			bui.cancel = nil
+			cancel()
+			bui.setThinking(false)
+
+			if !ok {
+				bui.Status.SetText(fmt.Sprintf("%s found no move", sideText(b.BlackTurn)))
+				return
+			}
+			if err := bui.play(x, y); err != nil {
+				bui.Status.SetText(fmt.Sprintf("%s played an illegal move: %v", sideText(b.BlackTurn), err))
+				return
+			}
+			bui.next()
+		})
+	}()
+}
+
+// ShowNewGameDialog asks who plays each side and starts that game.
+func ShowNewGameDialog(w fyne.Window, bui *BoardUI) {
+	blackKind, blackDepth := playerForm(bui.Config.Black)
+	whiteKind, whiteDepth := playerForm(bui.Config.White)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Black", blackKind),
+		widget.NewFormItem("Black strength", blackDepth),
+		widget.NewFormItem("White", whiteKind),
+		widget.NewFormItem("White strength", whiteDepth),
+	}
+
+	dialog.ShowForm("New game", "Start", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		cfg := GameConfig{
+			Black: PlayerConfig{Kind: blackKind.Selected, Depth: depthOf(blackDepth)},
+			White: PlayerConfig{Kind: whiteKind.Selected, Depth: depthOf(whiteDepth)},
+		}
+		if err := bui.NewGame(cfg); err != nil {
+			dialog.ShowError(err, w)
+		}
+	}, w)
+}
+
+// playerForm makes the widgets choosing one side's player.
+func playerForm(cfg PlayerConfig) (*widget.Select, *widget.Select) {
+	depths := make([]string, MaxDepth)
+	for i := range depths {
+		depths[i] = strconv.Itoa(i + 1)
+	}
+	depth := widget.NewSelect(depths, nil)
+	if cfg.Depth == 0 {
+		cfg.Depth = DefaultGame.White.Depth
+	}
+	depth.SetSelected(strconv.Itoa(cfg.Depth))
+
+	kind := widget.NewSelect(PlayerKinds, func(kind string) {
+		if kind == "Level" || kind == "Pengwin" || kind == "Greedy" {
+			depth.Enable()
+		} else {
+			depth.Disable()
+		}
+	})
+	kind.SetSelected(cfg.Kind)
+	return kind, depth
+}
+
+func depthOf(s *widget.Select) int {
+	depth, _ := strconv.Atoi(s.Selected)
+	return depth
+}
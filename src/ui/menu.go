@@ -0,0 +1,98 @@
+package ui
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"Othello-Engine/board"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+)
+
+// MainMenu is the window's menu: games, history and positions, and the
+// board's settings.
+func MainMenu(w fyne.Window, bui *BoardUI) *fyne.MainMenu {
+	file := fyne.NewMenu("File",
+		fyne.NewMenuItem("New Game…", func() { ShowNewGameDialog(w, bui) }),
+		fyne.NewMenuItem("Open Game…", func() { openGame(w, bui) }),
+		fyne.NewMenuItem("Save Game…", func() { saveGame(w, bui) }),
+	)
+	edit := fyne.NewMenu("Edit",
+		fyne.NewMenuItem("Undo", bui.Undo),
+		fyne.NewMenuItem("Redo", bui.Redo),
+		fyne.NewMenuItem("Resume", bui.Resume),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Copy Position", func() {
+			fyne.CurrentApp().Clipboard().SetContent(board.Position(bui.Game.Board()))
+		}),
+		fyne.NewMenuItem("Paste Position", func() { pastePosition(w, bui) }),
+		fyne.NewMenuItem("Edit Position", bui.Edit),
+	)
+	return fyne.NewMainMenu(file, edit, SettingsMenu(bui))
+}
+
+func openGame(w fyne.Window, bui *BoardUI) {
+	dialog.ShowFileOpen(func(r fyne.URIReadCloser, err error) {
+		if err != nil || r == nil {
+			showError(err, w)
+			return
+		}
+		defer r.Close()
+
+		data, err := io.ReadAll(r)
+		if err != nil {
+			showError(err, w)
+			return
+		}
+		g, err := board.ParseRecord(string(data))
+		if err != nil {
+			showError(err, w)
+			return
+		}
+		bui.Load(g)
+	}, w)
+}
+
+func saveGame(w fyne.Window, bui *BoardUI) {
+	save := dialog.NewFileSave(func(wc fyne.URIWriteCloser, err error) {
+		if err != nil || wc == nil {
+			showError(err, w)
+			return
+		}
+
+		_, err = io.WriteString(wc, board.FormatRecord(bui.Game, wc.URI().Name()))
+		if cerr := wc.Close(); err == nil {
+			err = cerr
+		}
+		showError(err, w)
+	}, w)
+	save.SetFileName("game.ggf")
+	save.Show()
+}
+
+func pastePosition(w fyne.Window, bui *BoardUI) {
+	text := fyne.CurrentApp().Clipboard().Content()
+	if strings.TrimSpace(text) == "" {
+		showError(errors.New("the clipboard is empty"), w)
+		return
+	}
+
+	b, err := board.ParsePosition(text)
+	if err == nil {
+		err = b.Validate()
+	}
+	if err != nil {
+		showError(err, w)
+		return
+	}
+	bui.Load(board.NewGame(b))
+}
+
+// showError shows err, if any.
+func showError(err error, w fyne.Window) {
+	if err != nil {
+		dialog.ShowError(err, w)
+	}
+}